@@ -0,0 +1,77 @@
+// Package bson converts between `njson.Node` values and BSON documents.
+//
+// ## Encoding
+//
+// Objects are encoded as BSON documents and arrays as BSON arrays.
+// Only object nodes can be encoded at the top level.
+// Numbers without a fraction or exponent are encoded as int32 or int64 depending on their value.
+// Integers that overflow int64 and numbers with a fraction or exponent are encoded as double.
+//
+// Objects in MongoDB Extended JSON v2 form are encoded as the BSON type they describe.
+// Both canonical and relaxed forms are accepted along with the legacy `{"$date":N}`
+// and `{"$binary":"...","$type":"..."}` forms.
+//
+// ## Decoding
+//
+// BSON documents decode to objects and BSON arrays to arrays.
+// BSON types that have no JSON equivalent decode to their Extended JSON v2 form
+// (`{"$oid":"..."}`, `{"$date":"..."}`, `{"$binary":{...}}` etc).
+// By default the relaxed form is used so int32, int64 and finite double values
+// decode to plain numbers. `Decoder.Canonical` enables the canonical form.
+//
+// Duplicate keys keep the last value. Nesting deeper than `Decoder.MaxDepth`
+// (`DefaultMaxDepth` if not set) is an error.
+package bson
+
+import "github.com/alxarch/njson/internal/codec"
+
+// Element types
+const (
+	typeDouble     byte = 0x01
+	typeString     byte = 0x02
+	typeDocument   byte = 0x03
+	typeArray      byte = 0x04
+	typeBinary     byte = 0x05
+	typeUndefined  byte = 0x06
+	typeObjectID   byte = 0x07
+	typeBoolean    byte = 0x08
+	typeDateTime   byte = 0x09
+	typeNull       byte = 0x0A
+	typeRegex      byte = 0x0B
+	typeDBPointer  byte = 0x0C
+	typeCode       byte = 0x0D
+	typeSymbol     byte = 0x0E
+	typeCodeScope  byte = 0x0F
+	typeInt32      byte = 0x10
+	typeTimestamp  byte = 0x11
+	typeInt64      byte = 0x12
+	typeDecimal128 byte = 0x13
+	typeMinKey     byte = 0xFF
+	typeMaxKey     byte = 0x7F
+)
+
+// Extended JSON keys
+const (
+	keyOID               = "$oid"
+	keyDate              = "$date"
+	keyNumberInt         = "$numberInt"
+	keyNumberLong        = "$numberLong"
+	keyNumberDouble      = "$numberDouble"
+	keyNumberDecimal     = "$numberDecimal"
+	keyBinary            = "$binary"
+	keyType              = "$type"
+	keyRegularExpression = "$regularExpression"
+	keyTimestamp         = "$timestamp"
+	keyMinKey            = "$minKey"
+	keyMaxKey            = "$maxKey"
+	keyUndefined         = "$undefined"
+	keySymbol            = "$symbol"
+	keyCode              = "$code"
+	keyScope             = "$scope"
+	keyDBPointer         = "$dbPointer"
+	keyRef               = "$ref"
+	keyID                = "$id"
+)
+
+// DecodeError signifies invalid BSON data.
+type DecodeError = codec.DecodeError
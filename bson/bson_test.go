@@ -0,0 +1,204 @@
+package bson
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/alxarch/njson"
+)
+
+func assertNoError(t *testing.T, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+}
+
+func assertEqual(t *testing.T, a, b interface{}) {
+	t.Helper()
+	if !reflect.DeepEqual(a, b) {
+		t.Fatalf("Assertion failed: %v != %v", a, b)
+	}
+}
+
+func TestAppendBSON(t *testing.T) {
+	for _, tc := range []struct {
+		json string
+		hex  string
+	}{
+		{`{}`, "0500000000"},
+		{`{"hello":"world"}`, "160000000268656c6c6f0006000000776f726c640000"},
+		{`{"a":1}`, "0c0000001061000100000000"},
+		{`{"a":4294967296}`, "10000000126100000000000100000000"},
+		{`{"a":1.5}`, "10000000016100000000000000f83f00"},
+		{`{"a":[true,null]}`, "140000000461000c000000083000010a31000000"},
+		{`{"a":{"$oid":"5f1d7a5b9d1e8a0001a1b2c3"}}`, "140000000761005f1d7a5b9d1e8a0001a1b2c300"},
+		{`{"a":{"$date":"1970-01-01T00:00:01.5Z"}}`, "10000000096100dc0500000000000000"},
+		{`{"a":{"$date":{"$numberLong":"-1"}}}`, "10000000096100ffffffffffffffff00"},
+		{`{"a":{"$numberLong":"1"}}`, "10000000126100010000000000000000"},
+		{`{"a":{"$binary":{"base64":"AQI=","subType":"80"}}}`, "0f0000000561000200000080010200"},
+	} {
+		d := njson.Document{}
+		n, _, err := d.Parse(tc.json)
+		assertNoError(t, err)
+		out, err := AppendBSON(nil, n)
+		assertNoError(t, err)
+		if h := hex.EncodeToString(out); h != tc.hex {
+			t.Errorf("Invalid encoding %s: %s != %s", tc.json, h, tc.hex)
+		}
+	}
+}
+
+func TestAppendBSON_Errors(t *testing.T) {
+	for _, src := range []string{
+		`[1]`,
+		`{"a":{"$oid":"foo"}}`,
+		`{"a":{"$date":true}}`,
+		`{"a":{"$numberInt":"4294967296"}}`,
+		`{"a\u0000b":1}`,
+	} {
+		d := njson.Document{}
+		n, _, err := d.Parse(src)
+		assertNoError(t, err)
+		if _, err := AppendBSON(nil, n); err == nil {
+			t.Errorf("Expected error for %s", src)
+		}
+	}
+}
+
+func TestDecode(t *testing.T) {
+	for _, tc := range []struct {
+		hex       string
+		json      string
+		canonical bool
+	}{
+		{"0500000000", `{}`, false},
+		{"160000000268656c6c6f0006000000776f726c640000", `{"hello":"world"}`, false},
+		{"0c0000001061000100000000", `{"a":1}`, false},
+		{"0c0000001061000100000000", `{"a":{"$numberInt":"1"}}`, true},
+		{"10000000126100000000000100000000", `{"a":4294967296}`, false},
+		{"10000000016100000000000000f83f00", `{"a":1.5}`, false},
+		{"10000000016100000000000000f03f00", `{"a":1.0}`, false},
+		{"10000000016100000000000000f03f00", `{"a":{"$numberDouble":"1.0"}}`, true},
+		{"10000000016100000000000000f07f00", `{"a":{"$numberDouble":"Infinity"}}`, false},
+		{"140000000761005f1d7a5b9d1e8a0001a1b2c300", `{"a":{"$oid":"5f1d7a5b9d1e8a0001a1b2c3"}}`, false},
+		{"10000000096100dc0500000000000000", `{"a":{"$date":"1970-01-01T00:00:01.5Z"}}`, false},
+		{"10000000096100dc0500000000000000", `{"a":{"$date":{"$numberLong":"1500"}}}`, true},
+		{"10000000096100ffffffffffffffff00", `{"a":{"$date":{"$numberLong":"-1"}}}`, false},
+		{"0f0000000561000200000080010200", `{"a":{"$binary":{"base64":"AQI=","subType":"80"}}}`, false},
+		{"100000000b6100615e62240069780000", `{"a":{"$regularExpression":{"pattern":"a^b$","options":"ix"}}}`, false},
+		{"10000000116100020000000100000000", `{"a":{"$timestamp":{"t":1,"i":2}}}`, false},
+		{"08000000ff610000", `{"a":{"$minKey":1}}`, false},
+		{"0800000006610000", `{"a":{"$undefined":true}}`, false},
+		{"180000001361000100000000000000000000000000403000", `{"a":{"$numberDecimal":"1"}}`, false},
+		{"140000000461000c000000083000010a31000000", `{"a":[true,null]}`, false},
+	} {
+		d := njson.Document{}
+		data, err := hex.DecodeString(tc.hex)
+		assertNoError(t, err)
+		dec := Decoder{Canonical: tc.canonical}
+		n, tail, err := dec.Decode(&d, data)
+		assertNoError(t, err)
+		assertEqual(t, len(tail), 0)
+		out, err := n.AppendJSON(nil)
+		assertNoError(t, err)
+		if string(out) != tc.json {
+			t.Errorf("Invalid decode %s: %s != %s", tc.hex, out, tc.json)
+		}
+	}
+}
+
+func TestDecode_Invalid(t *testing.T) {
+	for _, s := range []string{
+		"",
+		"05000000",
+		"0600000000",
+		"0500000001",
+		"0c0000001061000100000001",
+		"0d000000026100ffffffff0000",
+		"0c0000002061000100000000",
+		"09000000086100020000",
+	} {
+		d := njson.Document{}
+		data, err := hex.DecodeString(s)
+		assertNoError(t, err)
+		if _, _, err := Decode(&d, data); err == nil {
+			t.Errorf("Expected error for %s", s)
+		} else if _, ok := err.(*DecodeError); !ok {
+			t.Errorf("Invalid error for %s: %v", s, err)
+		}
+	}
+}
+
+// nestedDocument returns a BSON document with depth levels of embedded documents.
+func nestedDocument(depth int) []byte {
+	var data []byte
+	for i := depth; i > 0; i-- {
+		data = append(data, 0, 0, 0, 0, 0x03, 'a', 0)
+		binary.LittleEndian.PutUint32(data[len(data)-7:], uint32(5+8*i))
+	}
+	data = append(data, 5, 0, 0, 0, 0)
+	return append(data, make([]byte, depth)...)
+}
+
+func TestDecode_MaxDepth(t *testing.T) {
+	d := njson.Document{}
+	_, _, err := Decode(&d, nestedDocument(1<<17))
+	if _, ok := err.(*DecodeError); !ok {
+		t.Errorf("Invalid error: %v", err)
+	}
+	dec := Decoder{MaxDepth: 2}
+	n, _, err := dec.Decode(&d, nestedDocument(1))
+	assertNoError(t, err)
+	data, err := n.AppendJSON(nil)
+	assertNoError(t, err)
+	assertEqual(t, string(data), `{"a":{}}`)
+	_, _, err = dec.Decode(&d, nestedDocument(2))
+	if _, ok := err.(*DecodeError); !ok {
+		t.Errorf("Invalid error: %v", err)
+	}
+}
+
+func TestDecode_LargeDocument(t *testing.T) {
+	const size = 1 << 17
+	data := []byte{0, 0, 0, 0}
+	for i := 0; i < size; i++ {
+		data = append(data, 0x0a)
+		data = strconv.AppendInt(data, int64(i), 10)
+		data = append(data, 0)
+	}
+	// Duplicate keys replace previous values
+	data = append(data, 0x08, '0', 0, 1, 0)
+	binary.LittleEndian.PutUint32(data, uint32(len(data)))
+	d := njson.Document{}
+	n, _, err := Decode(&d, data)
+	assertNoError(t, err)
+	values := n.Values()
+	assertEqual(t, values.Len(), size)
+	assertEqual(t, n.Get("0").Raw(), "true")
+	assertEqual(t, n.Get("1").Raw(), "null")
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, src := range []string{
+		`{"_id":{"$oid":"5f1d7a5b9d1e8a0001a1b2c3"},"name":"foo \"bar\"","n":42,"big":9007199254740993,"f":-1.25,"ok":false,"tags":["a",{"b":null}]}`,
+		`{"created":{"$date":"2020-07-26T12:34:56.789Z"},"old":{"$date":{"$numberLong":"-62135596800000"}}}`,
+		`{"code":{"$code":"x + 1","$scope":{"x":1}},"sym":{"$symbol":"s"},"max":{"$maxKey":1}}`,
+		`{"ptr":{"$dbPointer":{"$ref":"db.coll","$id":{"$oid":"5f1d7a5b9d1e8a0001a1b2c3"}}}}`,
+		`{"d1":{"$numberDecimal":"1.05E+3"},"d2":{"$numberDecimal":"-0.0001"},"d3":{"$numberDecimal":"-Infinity"},"d4":{"$numberDecimal":"1.000000000000000000000000000000000E+6144"}}`,
+	} {
+		d := njson.Document{}
+		n, _, err := d.Parse(src)
+		assertNoError(t, err)
+		data, err := AppendBSON(nil, n)
+		assertNoError(t, err)
+		v, _, err := Decode(&d, data)
+		assertNoError(t, err)
+		out, err := v.AppendJSON(nil)
+		assertNoError(t, err)
+		assertEqual(t, string(out), src)
+	}
+}
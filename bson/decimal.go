@@ -0,0 +1,151 @@
+package bson
+
+import (
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Decimal128 limits
+const (
+	decimalExponentBias = 6176
+	decimalExponentMin  = -6176
+	decimalExponentMax  = 6111
+	decimalDigitsMax    = 34
+)
+
+var (
+	decimalCoefficientMax = new(big.Int).Sub(new(big.Int).Exp(big.NewInt(10), big.NewInt(decimalDigitsMax), nil), big.NewInt(1))
+	decimalMaskLow        = new(big.Int).SetUint64(1<<64 - 1)
+)
+
+// formatDecimal128 formats a decimal128 value as a string following the rules of the Extended JSON spec.
+func formatDecimal128(lo, hi uint64) string {
+	var (
+		neg   = hi>>63 == 1
+		exp   int
+		coeff = new(big.Int)
+	)
+	switch {
+	case hi>>58&0x1f == 0x1f:
+		return "NaN"
+	case hi>>58&0x1f == 0x1e:
+		if neg {
+			return "-Infinity"
+		}
+		return "Infinity"
+	case hi>>61&3 == 3:
+		// Non canonical coefficients are always greater than the maximum and are treated as zero
+		exp = int(hi>>47&0x3fff) - decimalExponentBias
+	default:
+		exp = int(hi>>49&0x3fff) - decimalExponentBias
+		coeff.SetUint64(hi & (1<<49 - 1))
+		coeff.Lsh(coeff, 64)
+		coeff.Or(coeff, new(big.Int).SetUint64(lo))
+		if coeff.Cmp(decimalCoefficientMax) > 0 {
+			coeff.SetUint64(0)
+		}
+	}
+	var (
+		digits   = coeff.String()
+		adjusted = exp + len(digits) - 1
+		s        strings.Builder
+	)
+	if neg {
+		s.WriteByte('-')
+	}
+	switch {
+	case exp <= 0 && adjusted >= -6:
+		if exp == 0 {
+			s.WriteString(digits)
+			break
+		}
+		// Plain notation with a decimal point
+		if n := len(digits) + exp; n > 0 {
+			s.WriteString(digits[:n])
+			s.WriteByte('.')
+			s.WriteString(digits[n:])
+		} else {
+			s.WriteString("0.")
+			s.WriteString(strings.Repeat("0", -n))
+			s.WriteString(digits)
+		}
+	default:
+		s.WriteByte(digits[0])
+		if len(digits) > 1 {
+			s.WriteByte('.')
+			s.WriteString(digits[1:])
+		}
+		s.WriteByte('E')
+		if adjusted >= 0 {
+			s.WriteByte('+')
+		}
+		s.WriteString(strconv.Itoa(adjusted))
+	}
+	return s.String()
+}
+
+// parseDecimal128 parses a decimal string to a decimal128 value.
+// Values that cannot be represented exactly are rejected.
+func parseDecimal128(s string) (lo, hi uint64, ok bool) {
+	var neg bool
+	switch {
+	case strings.HasPrefix(s, "-"):
+		neg, s = true, s[1:]
+	case strings.HasPrefix(s, "+"):
+		s = s[1:]
+	}
+	switch strings.ToLower(s) {
+	case "nan":
+		return 0, 0x1f << 58, true
+	case "inf", "infinity":
+		hi = 0x1e << 58
+		if neg {
+			hi |= 1 << 63
+		}
+		return 0, hi, true
+	}
+	var exp int
+	if i := strings.IndexAny(s, "eE"); i != -1 {
+		e, err := strconv.Atoi(s[i+1:])
+		if err != nil {
+			return 0, 0, false
+		}
+		exp, s = e, s[:i]
+	}
+	if i := strings.IndexByte(s, '.'); i != -1 {
+		exp -= len(s) - i - 1
+		s = s[:i] + s[i+1:]
+	}
+	if s == "" || strings.IndexFunc(s, func(r rune) bool { return r < '0' || r > '9' }) != -1 {
+		return 0, 0, false
+	}
+	coeff, _ := new(big.Int).SetString(s, 10)
+	if coeff.Cmp(decimalCoefficientMax) > 0 {
+		return 0, 0, false
+	}
+	// Clamp the exponent by adding trailing zeros to the coefficient
+	ten := big.NewInt(10)
+	for exp > decimalExponentMax && coeff.Sign() != 0 && coeff.Cmp(decimalCoefficientMax) <= 0 {
+		coeff.Mul(coeff, ten)
+		exp--
+	}
+	if coeff.Sign() == 0 {
+		switch {
+		case exp > decimalExponentMax:
+			exp = decimalExponentMax
+		case exp < decimalExponentMin:
+			exp = decimalExponentMin
+		}
+	}
+	if exp < decimalExponentMin || exp > decimalExponentMax || coeff.Cmp(decimalCoefficientMax) > 0 {
+		return 0, 0, false
+	}
+	lo = new(big.Int).And(coeff, decimalMaskLow).Uint64()
+	hi = new(big.Int).Rsh(coeff, 64).Uint64()
+	hi |= uint64(exp+decimalExponentBias) << 49
+	if neg {
+		hi |= 1 << 63
+	}
+	return lo, hi, true
+}
@@ -0,0 +1,385 @@
+package bson
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/alxarch/njson"
+	"github.com/alxarch/njson/internal/codec"
+	"github.com/alxarch/njson/numjson"
+	"github.com/alxarch/njson/strjson"
+)
+
+// Decoder decodes BSON documents to njson nodes.
+type Decoder struct {
+	Canonical bool // Use canonical Extended JSON form for numbers and dates
+	MaxDepth  int  // Maximum nesting depth of documents, DefaultMaxDepth if zero
+}
+
+// DefaultMaxDepth is the maximum nesting depth of documents if Decoder.MaxDepth is not set.
+const DefaultMaxDepth = codec.DefaultMaxDepth
+
+// Decode decodes a BSON document into a document using the default options.
+// It returns the new object node and the remaining data.
+func Decode(d *njson.Document, data []byte) (njson.Node, []byte, error) {
+	dec := Decoder{}
+	return dec.Decode(d, data)
+}
+
+// Decode decodes a BSON document into a document.
+// It returns the new object node and the remaining data.
+func (dec *Decoder) Decode(d *njson.Document, data []byte) (njson.Node, []byte, error) {
+	p := decodeState{
+		Decoder: dec,
+		State:   codec.State{Format: "BSON"},
+		doc:     d,
+		data:    data,
+	}
+	n, err := p.document(false)
+	if err != nil {
+		return njson.Node{}, data, err
+	}
+	return n, data[p.Pos:], nil
+}
+
+type decodeState struct {
+	*Decoder
+	codec.State
+	doc  *njson.Document
+	data []byte
+}
+
+func (p *decodeState) next(n int) ([]byte, error) {
+	if n < 0 || n > len(p.data)-p.Pos {
+		return nil, p.EOF()
+	}
+	buf := p.data[p.Pos : p.Pos+n]
+	p.Pos += n
+	return buf, nil
+}
+
+func (p *decodeState) int32() (int32, error) {
+	buf, err := p.next(4)
+	if err != nil {
+		return 0, err
+	}
+	return int32(binary.LittleEndian.Uint32(buf)), nil
+}
+
+func (p *decodeState) uint64() (uint64, error) {
+	buf, err := p.next(8)
+	if err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint64(buf), nil
+}
+
+func (p *decodeState) cstring() (string, error) {
+	i := bytes.IndexByte(p.data[p.Pos:], 0)
+	if i == -1 {
+		return "", p.EOF()
+	}
+	s := string(p.data[p.Pos : p.Pos+i])
+	p.Pos += i + 1
+	return s, nil
+}
+
+func (p *decodeState) string() (string, error) {
+	start := p.Pos
+	size, err := p.int32()
+	if err != nil {
+		return "", err
+	}
+	if size < 1 {
+		p.Pos = start
+		return "", p.Errorf("invalid string size")
+	}
+	buf, err := p.next(int(size))
+	if err != nil {
+		return "", err
+	}
+	if buf[len(buf)-1] != 0 {
+		p.Pos--
+		return "", p.Errorf("string is not NUL terminated")
+	}
+	return string(buf[:len(buf)-1]), nil
+}
+
+func (p *decodeState) objectID() (string, error) {
+	buf, err := p.next(12)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// text returns a new string node for s.
+func (p *decodeState) text(s string) njson.Node {
+	return p.doc.TextRaw(strjson.Escaped(s, false, false))
+}
+
+// wrap returns a new object node with a single key.
+func (p *decodeState) wrap(key string, v njson.Node) njson.Node {
+	obj := p.doc.Object()
+	obj.Set(key, v)
+	return obj
+}
+
+func (p *decodeState) document(array bool) (njson.Node, error) {
+	start := p.Pos
+	size, err := p.int32()
+	if err != nil {
+		return njson.Node{}, err
+	}
+	if size < 5 || int(size) > len(p.data)-start {
+		p.Pos = start
+		return njson.Node{}, p.Errorf("invalid document size")
+	}
+	end := start + int(size) - 1
+	if err := p.Enter(p.MaxDepth); err != nil {
+		return njson.Node{}, err
+	}
+	var n njson.Node
+	if array {
+		n = p.doc.Array()
+	} else {
+		n = p.doc.Object()
+	}
+	obj := codec.NewObjectBuilder(p.doc, n.ID())
+	for p.Pos < end {
+		typ := p.data[p.Pos]
+		p.Pos++
+		key, err := p.cstring()
+		if err != nil {
+			return njson.Node{}, err
+		}
+		v, err := p.value(typ)
+		if err != nil {
+			return njson.Node{}, err
+		}
+		if array {
+			n.Append(v)
+		} else {
+			obj.Set(strjson.Escaped(key, false, false), v.ID())
+		}
+	}
+	if p.Pos != end || p.data[end] != 0 {
+		p.Pos = start
+		return njson.Node{}, p.Errorf("invalid document size")
+	}
+	p.Pos++
+	p.Leave()
+	return n, nil
+}
+
+func (p *decodeState) value(typ byte) (njson.Node, error) {
+	d := p.doc
+	start := p.Pos
+	switch typ {
+	case typeDouble:
+		u, err := p.uint64()
+		if err != nil {
+			return njson.Node{}, err
+		}
+		return p.double(math.Float64frombits(u)), nil
+	case typeString:
+		s, err := p.string()
+		if err != nil {
+			return njson.Node{}, err
+		}
+		return p.text(s), nil
+	case typeDocument:
+		return p.document(false)
+	case typeArray:
+		return p.document(true)
+	case typeBinary:
+		size, err := p.int32()
+		if err != nil {
+			return njson.Node{}, err
+		}
+		if size < 0 {
+			p.Pos = start
+			return njson.Node{}, p.Errorf("invalid binary size")
+		}
+		buf, err := p.next(int(size) + 1)
+		if err != nil {
+			return njson.Node{}, err
+		}
+		bin := d.Object()
+		bin.Set("base64", d.TextRaw(base64.StdEncoding.EncodeToString(buf[1:])))
+		bin.Set("subType", d.TextRaw(hex.EncodeToString(buf[:1])))
+		return p.wrap(keyBinary, bin), nil
+	case typeUndefined:
+		return p.wrap(keyUndefined, d.True()), nil
+	case typeObjectID:
+		id, err := p.objectID()
+		if err != nil {
+			return njson.Node{}, err
+		}
+		return p.wrap(keyOID, d.TextRaw(id)), nil
+	case typeBoolean:
+		buf, err := p.next(1)
+		if err != nil {
+			return njson.Node{}, err
+		}
+		switch buf[0] {
+		case 0:
+			return d.False(), nil
+		case 1:
+			return d.True(), nil
+		}
+		p.Pos--
+		return njson.Node{}, p.Errorf("invalid boolean")
+	case typeDateTime:
+		u, err := p.uint64()
+		if err != nil {
+			return njson.Node{}, err
+		}
+		return p.date(int64(u)), nil
+	case typeNull:
+		return d.Null(), nil
+	case typeRegex:
+		pattern, err := p.cstring()
+		if err != nil {
+			return njson.Node{}, err
+		}
+		options, err := p.cstring()
+		if err != nil {
+			return njson.Node{}, err
+		}
+		re := d.Object()
+		re.Set("pattern", p.text(pattern))
+		re.Set("options", p.text(options))
+		return p.wrap(keyRegularExpression, re), nil
+	case typeDBPointer:
+		ref, err := p.string()
+		if err != nil {
+			return njson.Node{}, err
+		}
+		id, err := p.objectID()
+		if err != nil {
+			return njson.Node{}, err
+		}
+		ptr := d.Object()
+		ptr.Set(keyRef, p.text(ref))
+		ptr.Set(keyID, p.wrap(keyOID, d.TextRaw(id)))
+		return p.wrap(keyDBPointer, ptr), nil
+	case typeCode:
+		s, err := p.string()
+		if err != nil {
+			return njson.Node{}, err
+		}
+		return p.wrap(keyCode, p.text(s)), nil
+	case typeSymbol:
+		s, err := p.string()
+		if err != nil {
+			return njson.Node{}, err
+		}
+		return p.wrap(keySymbol, p.text(s)), nil
+	case typeCodeScope:
+		size, err := p.int32()
+		if err != nil {
+			return njson.Node{}, err
+		}
+		end := p.Pos - 4 + int(size)
+		s, err := p.string()
+		if err != nil {
+			return njson.Node{}, err
+		}
+		scope, err := p.document(false)
+		if err != nil {
+			return njson.Node{}, err
+		}
+		if p.Pos != end {
+			p.Pos = start
+			return njson.Node{}, p.Errorf("invalid code with scope size")
+		}
+		code := p.wrap(keyCode, p.text(s))
+		code.Set(keyScope, scope)
+		return code, nil
+	case typeInt32:
+		i, err := p.int32()
+		if err != nil {
+			return njson.Node{}, err
+		}
+		s := strconv.Itoa(int(i))
+		if p.Canonical {
+			return p.wrap(keyNumberInt, d.TextRaw(s)), nil
+		}
+		return d.NumberRaw(s), nil
+	case typeTimestamp:
+		u, err := p.uint64()
+		if err != nil {
+			return njson.Node{}, err
+		}
+		ts := d.Object()
+		ts.Set("t", d.NumberRaw(strconv.FormatUint(u>>32, 10)))
+		ts.Set("i", d.NumberRaw(strconv.FormatUint(u&math.MaxUint32, 10)))
+		return p.wrap(keyTimestamp, ts), nil
+	case typeInt64:
+		u, err := p.uint64()
+		if err != nil {
+			return njson.Node{}, err
+		}
+		s := strconv.FormatInt(int64(u), 10)
+		if p.Canonical {
+			return p.wrap(keyNumberLong, d.TextRaw(s)), nil
+		}
+		return d.NumberRaw(s), nil
+	case typeDecimal128:
+		lo, err := p.uint64()
+		if err != nil {
+			return njson.Node{}, err
+		}
+		hi, err := p.uint64()
+		if err != nil {
+			return njson.Node{}, err
+		}
+		return p.wrap(keyNumberDecimal, d.TextRaw(formatDecimal128(lo, hi))), nil
+	case typeMinKey:
+		return p.wrap(keyMinKey, d.NumberRaw("1")), nil
+	case typeMaxKey:
+		return p.wrap(keyMaxKey, d.NumberRaw("1")), nil
+	default:
+		p.Pos = start
+		return njson.Node{}, p.Errorf("invalid element type")
+	}
+}
+
+func (p *decodeState) double(f float64) njson.Node {
+	var s string
+	switch {
+	case math.IsNaN(f):
+		s = "NaN"
+	case math.IsInf(f, 1):
+		s = "Infinity"
+	case math.IsInf(f, -1):
+		s = "-Infinity"
+	default:
+		s = numjson.FormatFloat(f, 64)
+		if math.Trunc(f) == f && math.Abs(f) < 1e21 {
+			// Keep integral values distinct from int32 and int64
+			s += ".0"
+		}
+		if !p.Canonical {
+			return p.doc.NumberRaw(s)
+		}
+	}
+	return p.wrap(keyNumberDouble, p.doc.TextRaw(s))
+}
+
+func (p *decodeState) date(ms int64) njson.Node {
+	d := p.doc
+	if !p.Canonical {
+		tm := time.Unix(ms/1000, ms%1000*1e6).UTC()
+		if year := tm.Year(); 1970 <= year && year <= 9999 {
+			return p.wrap(keyDate, d.TextRaw(tm.Format("2006-01-02T15:04:05.999Z07:00")))
+		}
+	}
+	return p.wrap(keyDate, p.wrap(keyNumberLong, d.TextRaw(strconv.FormatInt(ms, 10))))
+}
@@ -0,0 +1,367 @@
+package bson
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alxarch/njson"
+	"github.com/alxarch/njson/numjson"
+	"github.com/alxarch/njson/strjson"
+)
+
+// AppendBSON appends the BSON encoding of an object node to a buffer.
+func AppendBSON(dst []byte, n njson.Node) ([]byte, error) {
+	if n.Type() != njson.TypeObject {
+		return dst, n.TypeError(njson.TypeObject)
+	}
+	return appendDocument(dst, n, false)
+}
+
+func appendDocument(dst []byte, n njson.Node, array bool) ([]byte, error) {
+	start := len(dst)
+	dst = append(dst, 0, 0, 0, 0)
+	var err error
+	for values := n.Values(); values.Next(); {
+		var key string
+		if array {
+			key = strconv.Itoa(values.Index())
+		} else {
+			key = strjson.Unescaped(values.Key())
+		}
+		if dst, err = appendElement(dst, key, values.Value()); err != nil {
+			return dst, err
+		}
+	}
+	dst = append(dst, 0)
+	return putSize(dst, start)
+}
+
+// putSize writes the int32 size of the data starting at offset start.
+func putSize(dst []byte, start int) ([]byte, error) {
+	size := len(dst) - start
+	if size > math.MaxInt32 {
+		return dst, fmt.Errorf("Document size %d overflows int32", size)
+	}
+	binary.LittleEndian.PutUint32(dst[start:], uint32(size))
+	return dst, nil
+}
+
+func appendElement(dst []byte, key string, v njson.Node) ([]byte, error) {
+	pos := len(dst)
+	dst = append(dst, 0)
+	dst, err := appendCString(dst, key)
+	if err != nil {
+		return dst, err
+	}
+	typ, dst, err := appendValue(dst, v)
+	if err != nil {
+		return dst, err
+	}
+	dst[pos] = typ
+	return dst, nil
+}
+
+func appendCString(dst []byte, s string) ([]byte, error) {
+	if strings.IndexByte(s, 0) != -1 {
+		return dst, fmt.Errorf("String %q contains a NUL byte", s)
+	}
+	return append(append(dst, s...), 0), nil
+}
+
+func appendString(dst []byte, s string) []byte {
+	dst = appendInt32(dst, int32(len(s)+1))
+	return append(append(dst, s...), 0)
+}
+
+func appendValue(dst []byte, v njson.Node) (byte, []byte, error) {
+	raw, typ := v.Data()
+	switch typ {
+	case njson.TypeNull:
+		return typeNull, dst, nil
+	case njson.TypeBoolean:
+		if raw == "true" {
+			return typeBoolean, append(dst, 1), nil
+		}
+		return typeBoolean, append(dst, 0), nil
+	case njson.TypeString:
+		return typeString, appendString(dst, strjson.Unescaped(raw)), nil
+	case njson.TypeNumber:
+		return appendNumber(dst, raw)
+	case njson.TypeArray:
+		dst, err := appendDocument(dst, v, true)
+		return typeArray, dst, err
+	case njson.TypeObject:
+		if typ, dst, ok, err := appendExtended(dst, v); ok || err != nil {
+			return typ, dst, err
+		}
+		dst, err := appendDocument(dst, v, false)
+		return typeDocument, dst, err
+	default:
+		return 0, dst, v.TypeError(njson.TypeAnyValue)
+	}
+}
+
+func appendNumber(dst []byte, raw string) (byte, []byte, error) {
+	if strings.IndexAny(raw, ".eE") == -1 {
+		if i, err := strconv.ParseInt(raw, 10, 64); err == nil {
+			if math.MinInt32 <= i && i <= math.MaxInt32 {
+				return typeInt32, appendInt32(dst, int32(i)), nil
+			}
+			return typeInt64, appendInt64(dst, i), nil
+		}
+	}
+	f, err := numjson.Parse(raw)
+	if err != nil {
+		return 0, dst, err
+	}
+	return typeDouble, appendDouble(dst, f), nil
+}
+
+// appendExtended encodes an object in Extended JSON form.
+// It returns false if the object is not in Extended JSON form.
+func appendExtended(dst []byte, n njson.Node) (byte, []byte, bool, error) {
+	values := n.Values()
+	if !values.Next() {
+		return 0, dst, false, nil
+	}
+	var (
+		key  = values.Key()
+		v    = values.Value()
+		size = values.Len()
+	)
+	if !strings.HasPrefix(key, "$") {
+		return 0, dst, false, nil
+	}
+	invalid := func() (byte, []byte, bool, error) {
+		return 0, dst, true, fmt.Errorf("Invalid Extended JSON %s value", key)
+	}
+	switch key {
+	case keyCode:
+		code, ok := toString(v)
+		if !ok {
+			return invalid()
+		}
+		if size == 1 {
+			return typeCode, appendString(dst, code), true, nil
+		}
+		scope := n.Get(keyScope)
+		if size != 2 || scope.Type() != njson.TypeObject {
+			return invalid()
+		}
+		start := len(dst)
+		dst = append(dst, 0, 0, 0, 0)
+		dst = appendString(dst, code)
+		dst, err := appendDocument(dst, scope, false)
+		if err != nil {
+			return 0, dst, true, err
+		}
+		dst, err = putSize(dst, start)
+		return typeCodeScope, dst, true, err
+	case keyBinary:
+		if v.Type() == njson.TypeString && size == 2 {
+			// Legacy {"$binary":"...","$type":"..."} form
+			return appendBinary(dst, v, n.Get(keyType))
+		}
+		if size != 1 {
+			return invalid()
+		}
+		return appendBinary(dst, v.Get("base64"), v.Get("subType"))
+	}
+	if size != 1 {
+		return 0, dst, false, nil
+	}
+	switch key {
+	case keyOID:
+		s, ok := toString(v)
+		if !ok || len(s) != 24 {
+			return invalid()
+		}
+		id, err := hex.DecodeString(s)
+		if err != nil {
+			return invalid()
+		}
+		return typeObjectID, append(dst, id...), true, nil
+	case keyDate:
+		var ms int64
+		switch v.Type() {
+		case njson.TypeString:
+			tm, err := time.Parse(time.RFC3339Nano, v.Unescaped())
+			if err != nil {
+				return invalid()
+			}
+			ms = tm.Unix()*1000 + int64(tm.Nanosecond()/1e6)
+		case njson.TypeNumber:
+			i, err := strconv.ParseInt(v.Raw(), 10, 64)
+			if err != nil {
+				return invalid()
+			}
+			ms = i
+		default:
+			i, ok := toInt(v, 64)
+			if !ok {
+				return invalid()
+			}
+			ms = i
+		}
+		return typeDateTime, appendInt64(dst, ms), true, nil
+	case keyNumberInt:
+		i, ok := toInt(n, 32)
+		if !ok {
+			return invalid()
+		}
+		return typeInt32, appendInt32(dst, int32(i)), true, nil
+	case keyNumberLong:
+		i, ok := toInt(n, 64)
+		if !ok {
+			return invalid()
+		}
+		return typeInt64, appendInt64(dst, i), true, nil
+	case keyNumberDouble:
+		s, ok := toString(v)
+		if !ok {
+			return invalid()
+		}
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return invalid()
+		}
+		return typeDouble, appendDouble(dst, f), true, nil
+	case keyNumberDecimal:
+		s, ok := toString(v)
+		if !ok {
+			return invalid()
+		}
+		lo, hi, ok := parseDecimal128(s)
+		if !ok {
+			return invalid()
+		}
+		dst = appendInt64(dst, int64(lo))
+		return typeDecimal128, appendInt64(dst, int64(hi)), true, nil
+	case keyRegularExpression:
+		pattern, ok := toString(v.Get("pattern"))
+		if !ok {
+			return invalid()
+		}
+		options, ok := toString(v.Get("options"))
+		if !ok {
+			return invalid()
+		}
+		dst, err := appendCString(dst, pattern)
+		if err != nil {
+			return 0, dst, true, err
+		}
+		dst, err = appendCString(dst, options)
+		return typeRegex, dst, true, err
+	case keyTimestamp:
+		t, ok := v.Get("t").ToUint()
+		if !ok || t > math.MaxUint32 {
+			return invalid()
+		}
+		i, ok := v.Get("i").ToUint()
+		if !ok || i > math.MaxUint32 {
+			return invalid()
+		}
+		return typeTimestamp, appendInt64(dst, int64(t<<32|i)), true, nil
+	case keyMinKey:
+		return typeMinKey, dst, true, nil
+	case keyMaxKey:
+		return typeMaxKey, dst, true, nil
+	case keyUndefined:
+		return typeUndefined, dst, true, nil
+	case keySymbol:
+		s, ok := toString(v)
+		if !ok {
+			return invalid()
+		}
+		return typeSymbol, appendString(dst, s), true, nil
+	case keyDBPointer:
+		ref, ok := toString(v.Get(keyRef))
+		if !ok {
+			return invalid()
+		}
+		s, ok := toString(v.Get(keyID).Get(keyOID))
+		if !ok || len(s) != 24 {
+			return invalid()
+		}
+		id, err := hex.DecodeString(s)
+		if err != nil {
+			return invalid()
+		}
+		dst = appendString(dst, ref)
+		return typeDBPointer, append(dst, id...), true, nil
+	default:
+		return 0, dst, false, nil
+	}
+}
+
+func appendBinary(dst []byte, data, subType njson.Node) (byte, []byte, bool, error) {
+	s, ok := toString(data)
+	if !ok {
+		return 0, dst, true, fmt.Errorf("Invalid Extended JSON %s value", keyBinary)
+	}
+	b, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return 0, dst, true, err
+	}
+	s, ok = toString(subType)
+	if !ok {
+		return 0, dst, true, fmt.Errorf("Invalid Extended JSON %s subtype", keyBinary)
+	}
+	sub, err := strconv.ParseUint(s, 16, 8)
+	if err != nil {
+		return 0, dst, true, err
+	}
+	dst = appendInt32(dst, int32(len(b)))
+	dst = append(dst, byte(sub))
+	return typeBinary, append(dst, b...), true, nil
+}
+
+// toString returns the unescaped value of a string node.
+func toString(n njson.Node) (string, bool) {
+	raw, typ := n.Data()
+	if typ != njson.TypeString {
+		return "", false
+	}
+	return strjson.Unescaped(raw), true
+}
+
+// toInt parses an integer from a string node or a {"$numberInt":"..."}/{"$numberLong":"..."} wrapper.
+func toInt(n njson.Node, bits int) (int64, bool) {
+	if n.Type() == njson.TypeObject {
+		key := keyNumberLong
+		if bits == 32 {
+			key = keyNumberInt
+		}
+		n = n.Get(key)
+	}
+	s, ok := toString(n)
+	if !ok {
+		return 0, false
+	}
+	i, err := strconv.ParseInt(s, 10, bits)
+	return i, err == nil
+}
+
+func appendInt32(dst []byte, i int32) []byte {
+	var buf [4]byte
+	binary.LittleEndian.PutUint32(buf[:], uint32(i))
+	return append(dst, buf[:]...)
+}
+
+func appendInt64(dst []byte, i int64) []byte {
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], uint64(i))
+	return append(dst, buf[:]...)
+}
+
+func appendDouble(dst []byte, f float64) []byte {
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], math.Float64bits(f))
+	return append(dst, buf[:]...)
+}
@@ -53,3 +53,16 @@ func TestIsSpaceASCII(t *testing.T) {
 		}
 	}
 }
+
+func TestIsSkipToken(t *testing.T) {
+	for i := 0; i < 255; i++ {
+		want := false
+		switch i {
+		case '"', '{', '}', '[', ']':
+			want = true
+		}
+		if isSkipToken(byte(i)) != want {
+			t.Fatalf("isSkipToken(%c) != %t", i, want)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+// Package cbor converts between `njson.Node` values and CBOR (RFC 8949) data.
+//
+// ## Encoding
+//
+// JSON values map to CBOR as follows:
+//   - Numbers without a fraction or exponent are encoded as integers (major types 0 and 1).
+//     Integers that do not fit in 64 bits are encoded as bignums (tags 2 and 3).
+//   - Other numbers are encoded as floats.
+//   - Strings are unescaped and encoded as text strings.
+//   - Arrays and objects are encoded as definite length arrays and maps with text string keys.
+//   - true, false and null are encoded as simple values.
+//
+// In deterministic mode the encoder follows the core deterministic encoding
+// requirements of RFC 8949 §4.2.1: floats use the shortest form that preserves
+// their value and map keys are sorted by their encoded bytes.
+//
+// ## Decoding
+//
+// CBOR data map to JSON values as described in RFC 8949 §6.1:
+//   - Integers and floats map to numbers. NaN and ±Infinity map to null.
+//   - Text strings map to strings.
+//   - Byte strings map to base64url encoded strings without padding.
+//     Tags 21, 22 and 23 change the encoding of nested byte strings to base64url, base64 and base16.
+//   - Bignums (tags 2 and 3) map to numbers.
+//   - Other tags are handled according to `Decoder.Tags`.
+//   - Maps map to objects. Integer and byte string keys are converted to strings.
+//   - undefined and unassigned simple values map to null unless `Decoder.DisallowUndefined` is set.
+//
+// Duplicate map keys keep the last value. Nesting deeper than `Decoder.MaxDepth`
+// (`DefaultMaxDepth` if not set) is an error.
+package cbor
+
+import "github.com/alxarch/njson/internal/codec"
+
+// Major types
+const (
+	majorUint byte = iota << 5
+	majorNint
+	majorBytes
+	majorText
+	majorArray
+	majorMap
+	majorTag
+	majorSimple
+)
+
+// Simple values and special additional info
+const (
+	simpleFalse     = 20
+	simpleTrue      = 21
+	simpleNull      = 22
+	simpleUndefined = 23
+	infoUint8       = 24
+	infoUint16      = 25
+	infoUint32      = 26
+	infoUint64      = 27
+	infoIndefinite  = 31
+	codeBreak       = 0xff
+)
+
+// Well known tags
+const (
+	tagPositiveBignum = 2
+	tagNegativeBignum = 3
+	tagBase64URL      = 21
+	tagBase64         = 22
+	tagBase16         = 23
+)
+
+// Keys of objects produced by TagWrap
+const (
+	keyTag   = "tag"
+	keyValue = "value"
+)
+
+// DecodeError signifies invalid CBOR data.
+type DecodeError = codec.DecodeError
@@ -0,0 +1,258 @@
+package cbor
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"reflect"
+	"testing"
+
+	"github.com/alxarch/njson"
+)
+
+func assertNoError(t *testing.T, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+}
+
+func assertEqual(t *testing.T, a, b interface{}) {
+	t.Helper()
+	if !reflect.DeepEqual(a, b) {
+		t.Fatalf("Assertion failed: %v != %v", a, b)
+	}
+}
+
+func mustHex(t *testing.T, s string) []byte {
+	t.Helper()
+	data, err := hex.DecodeString(s)
+	assertNoError(t, err)
+	return data
+}
+
+func TestDecode(t *testing.T) {
+	// Test vectors from RFC 8949 Appendix A
+	for _, tc := range []struct {
+		hex  string
+		json string
+	}{
+		{"00", `0`},
+		{"17", `23`},
+		{"1818", `24`},
+		{"1903e8", `1000`},
+		{"1bffffffffffffffff", `18446744073709551615`},
+		{"c249010000000000000000", `18446744073709551616`},
+		{"3bffffffffffffffff", `-18446744073709551616`},
+		{"c349010000000000000000", `-18446744073709551617`},
+		{"20", `-1`},
+		{"3903e7", `-1000`},
+		{"f90000", `0`},
+		{"f93c00", `1`},
+		{"f93e00", `1.5`},
+		{"f97bff", `65504`},
+		{"fa47c35000", `100000`},
+		{"fb3ff199999999999a", `1.1`},
+		{"fb7e37e43c8800759c", `1e+300`},
+		{"f90001", `5.9604645e-8`},
+		{"f97c00", `null`},
+		{"f97e00", `null`},
+		{"fbfff0000000000000", `null`},
+		{"f4", `false`},
+		{"f5", `true`},
+		{"f6", `null`},
+		{"f7", `null`},
+		{"f0", `null`},
+		{"f8ff", `null`},
+		{"c074323031332d30332d32315432303a30343a30305a", `"2013-03-21T20:04:00Z"`},
+		{"d74401020304", `"01020304"`},
+		{"d818456449455446", `"ZElFVEY"`},
+		{"4401020304", `"AQIDBA"`},
+		{"6449455446", `"IETF"`},
+		{"62225c", `"\"\\"`},
+		{"63e6b0b4", `"水"`},
+		{"83010203", `[1,2,3]`},
+		{"8301820203820405", `[1,[2,3],[4,5]]`},
+		{"a201020304", `{"1":2,"3":4}`},
+		{"a26161016162820203", `{"a":1,"b":[2,3]}`},
+		{"5f42010243030405ff", `"AQIDBAU"`},
+		{"7f657374726561646d696e67ff", `"streaming"`},
+		{"9fff", `[]`},
+		{"9f018202039f0405ffff", `[1,[2,3],[4,5]]`},
+		{"bf61610161629f0203ffff", `{"a":1,"b":[2,3]}`},
+		{"d6a1614101", `{"A":1}`},
+	} {
+		d := njson.Document{}
+		n, tail, err := Decode(&d, mustHex(t, tc.hex))
+		assertNoError(t, err)
+		assertEqual(t, len(tail), 0)
+		out, err := n.AppendJSON(nil)
+		assertNoError(t, err)
+		if string(out) != tc.json {
+			t.Errorf("Invalid decode %s: %s != %s", tc.hex, out, tc.json)
+		}
+	}
+}
+
+func TestDecode_Invalid(t *testing.T) {
+	for _, s := range []string{
+		"",
+		"18",
+		"6222",
+		"9f01",
+		"a16161",
+		"1c",
+		"ff",
+		"5f6161ff",
+		"a1f500",
+		"c26161",
+	} {
+		d := njson.Document{}
+		_, _, err := Decode(&d, mustHex(t, s))
+		if _, ok := err.(*DecodeError); !ok {
+			t.Errorf("Invalid error for %q: %v", s, err)
+		}
+	}
+}
+
+func TestDecoder_Options(t *testing.T) {
+	d := njson.Document{}
+	data := mustHex(t, "c11a514b67b0")
+	dec := Decoder{Tags: TagWrap}
+	n, _, err := dec.Decode(&d, data)
+	assertNoError(t, err)
+	out, err := n.AppendJSON(nil)
+	assertNoError(t, err)
+	assertEqual(t, string(out), `{"tag":1,"value":1363896240}`)
+
+	dec = Decoder{Tags: TagError}
+	_, _, err = dec.Decode(&d, data)
+	if _, ok := err.(*DecodeError); !ok {
+		t.Errorf("Invalid error: %v", err)
+	}
+
+	dec = Decoder{Bytes: BytesBase64}
+	n, _, err = dec.Decode(&d, mustHex(t, "43010203"))
+	assertNoError(t, err)
+	assertEqual(t, n.Raw(), "AQID")
+
+	dec = Decoder{DisallowUndefined: true}
+	_, _, err = dec.Decode(&d, mustHex(t, "f7"))
+	if _, ok := err.(*DecodeError); !ok {
+		t.Errorf("Invalid error: %v", err)
+	}
+
+	n, tail, err := Decode(&d, mustHex(t, "0102"))
+	assertNoError(t, err)
+	assertEqual(t, n.Raw(), "1")
+	assertEqual(t, tail, []byte{2})
+}
+
+func TestDecode_MaxDepth(t *testing.T) {
+	for _, c := range []byte{0x81, 0xa1, 0xc6} {
+		data := bytes.Repeat([]byte{c}, 1<<20)
+		d := njson.Document{}
+		_, _, err := Decode(&d, data)
+		if _, ok := err.(*DecodeError); !ok {
+			t.Errorf("Invalid error for %x: %v", c, err)
+		}
+	}
+	d := njson.Document{}
+	dec := Decoder{MaxDepth: 2}
+	_, _, err := dec.Decode(&d, mustHex(t, "818100"))
+	assertNoError(t, err)
+	_, _, err = dec.Decode(&d, mustHex(t, "81818100"))
+	if _, ok := err.(*DecodeError); !ok {
+		t.Errorf("Invalid error: %v", err)
+	}
+}
+
+func TestDecode_LargeMap(t *testing.T) {
+	const size = 1 << 17
+	data := []byte{0xba, 0, 0, 0, 0}
+	binary.BigEndian.PutUint32(data[1:], size+1)
+	for i := 0; i < size; i++ {
+		data = append(data, 0x1a, 0, 0, 0, 0, 0x00)
+		binary.BigEndian.PutUint32(data[len(data)-5:], uint32(i))
+	}
+	// Duplicate keys replace previous values
+	data = append(data, 0x00, 0x01)
+	d := njson.Document{}
+	n, _, err := Decode(&d, data)
+	assertNoError(t, err)
+	values := n.Values()
+	assertEqual(t, values.Len(), size)
+	assertEqual(t, n.Get("0").Raw(), "1")
+	assertEqual(t, n.Get("1").Raw(), "0")
+}
+
+func TestEncoder(t *testing.T) {
+	for _, tc := range []struct {
+		json          string
+		hex           string
+		deterministic bool
+	}{
+		{`0`, "00", false},
+		{`1000`, "1903e8", false},
+		{`-1000`, "3903e7", false},
+		{`18446744073709551615`, "1bffffffffffffffff", false},
+		{`18446744073709551616`, "c249010000000000000000", false},
+		{`-18446744073709551617`, "c349010000000000000000", false},
+		{`1.5`, "fb3ff8000000000000", false},
+		{`1.5`, "f93e00", true},
+		{`100000.0`, "fa47c35000", true},
+		{`1.1`, "fb3ff199999999999a", true},
+		{`"\"\\"`, "62225c", false},
+		{`"水"`, "63e6b0b4", false},
+		{`[1,[2,3],[4,5]]`, "8301820203820405", false},
+		{`{"b":[2,3],"a":1}`, "a26162820203616101", false},
+		{`{"b":[2,3],"a":1}`, "a26161016162820203", true},
+		{`{"aa":1,"b":2}`, "a261620262616101", true},
+		{`[true,false,null]`, "83f5f4f6", false},
+	} {
+		d := njson.Document{}
+		n, _, err := d.Parse(tc.json)
+		assertNoError(t, err)
+		e := Encoder{Deterministic: tc.deterministic}
+		out, err := e.AppendCBOR(nil, n)
+		assertNoError(t, err)
+		if h := hex.EncodeToString(out); h != tc.hex {
+			t.Errorf("Invalid encoding %s: %s != %s", tc.json, h, tc.hex)
+		}
+	}
+}
+
+func TestEncoder_Errors(t *testing.T) {
+	d := njson.Document{}
+	n, _, err := d.Parse(`{"a":1,"a":2}`)
+	assertNoError(t, err)
+	e := Encoder{Deterministic: true}
+	if _, err := e.AppendCBOR(nil, n); err == nil {
+		t.Errorf("Expected duplicate key error")
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	src := `{"id":42,"name":"sensor \"A\"","temp":-12.5,"ok":true,"data":null,"tags":["a","b"],"big":123456789012345678901234567890}`
+	d := njson.Document{}
+	n, _, err := d.Parse(src)
+	assertNoError(t, err)
+	e := Encoder{Deterministic: true}
+	data, err := e.AppendCBOR(nil, n)
+	assertNoError(t, err)
+	v, tail, err := Decode(&d, data)
+	assertNoError(t, err)
+	assertEqual(t, len(tail), 0)
+	want, _ := n.ToInterface()
+	got, _ := v.ToInterface()
+	assertEqual(t, got, want)
+
+	// Tags survive a round trip with TagWrap and UnwrapTags
+	dec := Decoder{Tags: TagWrap}
+	v, _, err = dec.Decode(&d, mustHex(t, "d8208200f5"))
+	assertNoError(t, err)
+	e = Encoder{UnwrapTags: true}
+	data, err = e.AppendCBOR(nil, v)
+	assertNoError(t, err)
+	assertEqual(t, hex.EncodeToString(data), "d8208200f5")
+}
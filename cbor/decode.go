@@ -0,0 +1,398 @@
+package cbor
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"math"
+	"math/big"
+	"strconv"
+
+	"github.com/alxarch/njson"
+	"github.com/alxarch/njson/internal/codec"
+	"github.com/alxarch/njson/numjson"
+	"github.com/alxarch/njson/strjson"
+)
+
+// TagMode controls how the decoder handles tags other than the well known ones.
+type TagMode int
+
+// Tag modes
+const (
+	TagContent TagMode = iota // Decode the enclosed data item ignoring the tag
+	TagWrap                   // Decode to an object of the form {"tag":N,"value":V}
+	TagError                  // Fail with an error
+)
+
+// BytesMode controls how byte strings are converted to strings.
+type BytesMode int
+
+// Byte string modes
+const (
+	BytesBase64URL BytesMode = iota // base64url without padding
+	BytesBase64                     // base64 with padding
+	BytesHex                        // lowercase base16
+)
+
+func (m BytesMode) encode(data []byte) string {
+	switch m {
+	case BytesBase64:
+		return base64.StdEncoding.EncodeToString(data)
+	case BytesHex:
+		return hex.EncodeToString(data)
+	default:
+		return base64.RawURLEncoding.EncodeToString(data)
+	}
+}
+
+// Decoder decodes CBOR data to njson nodes.
+type Decoder struct {
+	Tags              TagMode
+	Bytes             BytesMode
+	DisallowUndefined bool // Fail on undefined and unassigned simple values
+	MaxDepth          int  // Maximum nesting depth of arrays, maps and tags, DefaultMaxDepth if zero
+}
+
+// DefaultMaxDepth is the maximum nesting depth of data items if Decoder.MaxDepth is not set.
+const DefaultMaxDepth = codec.DefaultMaxDepth
+
+// Decode decodes a CBOR data item into a document using the default options.
+// It returns the new node and the remaining data.
+func Decode(d *njson.Document, data []byte) (njson.Node, []byte, error) {
+	dec := Decoder{}
+	return dec.Decode(d, data)
+}
+
+// Decode decodes a CBOR data item into a document.
+// It returns the new node and the remaining data.
+func (dec *Decoder) Decode(d *njson.Document, data []byte) (njson.Node, []byte, error) {
+	p := decodeState{
+		Decoder: dec,
+		State:   codec.State{Format: "CBOR"},
+		doc:     d,
+		data:    data,
+	}
+	n, err := p.decode(dec.Bytes)
+	if err != nil {
+		return njson.Node{}, data, err
+	}
+	return n, data[p.Pos:], nil
+}
+
+type decodeState struct {
+	*Decoder
+	codec.State
+	doc  *njson.Document
+	data []byte
+}
+
+// head reads the initial bytes of a data item.
+// For indefinite length items it returns infoIndefinite as info.
+func (p *decodeState) head() (major, info byte, arg uint64, err error) {
+	if p.Pos >= len(p.data) {
+		return 0, 0, 0, p.EOF()
+	}
+	c := p.data[p.Pos]
+	p.Pos++
+	major, info = c&0xe0, c&0x1f
+	switch {
+	case info < infoUint8:
+		return major, info, uint64(info), nil
+	case info <= infoUint64:
+		size := 1 << (info - infoUint8)
+		if p.Pos+size > len(p.data) {
+			return 0, 0, 0, p.EOF()
+		}
+		buf := p.data[p.Pos : p.Pos+size]
+		p.Pos += size
+		switch size {
+		case 1:
+			arg = uint64(buf[0])
+		case 2:
+			arg = uint64(binary.BigEndian.Uint16(buf))
+		case 4:
+			arg = uint64(binary.BigEndian.Uint32(buf))
+		default:
+			arg = binary.BigEndian.Uint64(buf)
+		}
+		return major, info, arg, nil
+	case info == infoIndefinite:
+		switch major {
+		case majorBytes, majorText, majorArray, majorMap:
+			return major, info, 0, nil
+		}
+	}
+	p.Pos--
+	return 0, 0, 0, p.Errorf("invalid additional information")
+}
+
+// isBreak consumes a break code if it is the next byte.
+func (p *decodeState) isBreak() (bool, error) {
+	if p.Pos >= len(p.data) {
+		return false, p.EOF()
+	}
+	if p.data[p.Pos] == codeBreak {
+		p.Pos++
+		return true, nil
+	}
+	return false, nil
+}
+
+// bytes reads the payload of a byte or text string.
+func (p *decodeState) bytes(major, info byte, size uint64) ([]byte, error) {
+	if info != infoIndefinite {
+		if size > uint64(len(p.data)-p.Pos) {
+			return nil, p.EOF()
+		}
+		buf := p.data[p.Pos : p.Pos+int(size)]
+		p.Pos += int(size)
+		return buf, nil
+	}
+	var buf []byte
+	for {
+		if end, err := p.isBreak(); err != nil {
+			return nil, err
+		} else if end {
+			return buf, nil
+		}
+		m, i, n, err := p.head()
+		if err != nil {
+			return nil, err
+		}
+		if m != major || i == infoIndefinite {
+			return nil, p.Errorf("invalid indefinite length string chunk")
+		}
+		chunk, err := p.bytes(m, i, n)
+		if err != nil {
+			return nil, err
+		}
+		buf = append(buf, chunk...)
+	}
+}
+
+func (p *decodeState) decode(mode BytesMode) (njson.Node, error) {
+	d := p.doc
+	start := p.Pos
+	major, info, arg, err := p.head()
+	if err != nil {
+		return njson.Node{}, err
+	}
+	switch major {
+	case majorUint:
+		return d.NumberRaw(strconv.FormatUint(arg, 10)), nil
+	case majorNint:
+		return d.NumberRaw(formatNint(arg)), nil
+	case majorBytes:
+		data, err := p.bytes(major, info, arg)
+		if err != nil {
+			return njson.Node{}, err
+		}
+		return d.TextRaw(mode.encode(data)), nil
+	case majorText:
+		data, err := p.bytes(major, info, arg)
+		if err != nil {
+			return njson.Node{}, err
+		}
+		return d.TextRaw(strjson.Escaped(string(data), false, false)), nil
+	case majorArray:
+		if err := p.Enter(p.MaxDepth); err != nil {
+			return njson.Node{}, err
+		}
+		arr := d.Array()
+		for i := uint64(0); info == infoIndefinite || i < arg; i++ {
+			if info == infoIndefinite {
+				if end, err := p.isBreak(); err != nil {
+					return njson.Node{}, err
+				} else if end {
+					break
+				}
+			} else if arg-i > uint64(len(p.data)-p.Pos) {
+				// Each item needs at least one byte
+				return njson.Node{}, p.EOF()
+			}
+			el, err := p.decode(mode)
+			if err != nil {
+				return njson.Node{}, err
+			}
+			arr.Append(el)
+		}
+		p.Leave()
+		return arr, nil
+	case majorMap:
+		if err := p.Enter(p.MaxDepth); err != nil {
+			return njson.Node{}, err
+		}
+		obj := d.Object()
+		b := codec.NewObjectBuilder(d, obj.ID())
+		for i := uint64(0); info == infoIndefinite || i < arg; i++ {
+			if info == infoIndefinite {
+				if end, err := p.isBreak(); err != nil {
+					return njson.Node{}, err
+				} else if end {
+					break
+				}
+			} else if arg-i > uint64(len(p.data)-p.Pos)/2 {
+				// Each entry needs at least two bytes
+				return njson.Node{}, p.EOF()
+			}
+			key, err := p.key(mode)
+			if err != nil {
+				return njson.Node{}, err
+			}
+			v, err := p.decode(mode)
+			if err != nil {
+				return njson.Node{}, err
+			}
+			b.Set(key, v.ID())
+		}
+		p.Leave()
+		return obj, nil
+	case majorTag:
+		if err := p.Enter(p.MaxDepth); err != nil {
+			return njson.Node{}, err
+		}
+		n, err := p.tag(start, arg, mode)
+		p.Leave()
+		return n, err
+	default:
+		return p.simple(start, info, arg)
+	}
+}
+
+// key decodes a map key to an escaped string.
+func (p *decodeState) key(mode BytesMode) (string, error) {
+	start := p.Pos
+	major, info, arg, err := p.head()
+	if err != nil {
+		return "", err
+	}
+	switch major {
+	case majorText:
+		data, err := p.bytes(major, info, arg)
+		if err != nil {
+			return "", err
+		}
+		return strjson.Escaped(string(data), false, false), nil
+	case majorBytes:
+		data, err := p.bytes(major, info, arg)
+		if err != nil {
+			return "", err
+		}
+		return mode.encode(data), nil
+	case majorUint:
+		return strconv.FormatUint(arg, 10), nil
+	case majorNint:
+		return formatNint(arg), nil
+	default:
+		p.Pos = start
+		return "", p.Errorf("unsupported map key type")
+	}
+}
+
+func (p *decodeState) tag(start int, tag uint64, mode BytesMode) (njson.Node, error) {
+	switch tag {
+	case tagPositiveBignum, tagNegativeBignum:
+		major, info, arg, err := p.head()
+		if err != nil {
+			return njson.Node{}, err
+		}
+		if major != majorBytes {
+			p.Pos = start
+			return njson.Node{}, p.Errorf("invalid bignum")
+		}
+		data, err := p.bytes(major, info, arg)
+		if err != nil {
+			return njson.Node{}, err
+		}
+		b := new(big.Int).SetBytes(data)
+		if tag == tagNegativeBignum {
+			b.Add(b, big.NewInt(1))
+			b.Neg(b)
+		}
+		return p.doc.NumberRaw(b.String()), nil
+	case tagBase64URL:
+		return p.decode(BytesBase64URL)
+	case tagBase64:
+		return p.decode(BytesBase64)
+	case tagBase16:
+		return p.decode(BytesHex)
+	}
+	switch p.Tags {
+	case TagWrap:
+		v, err := p.decode(mode)
+		if err != nil {
+			return njson.Node{}, err
+		}
+		obj := p.doc.Object()
+		obj.Set(keyTag, p.doc.NumberRaw(strconv.FormatUint(tag, 10)))
+		obj.Set(keyValue, v)
+		return obj, nil
+	case TagError:
+		p.Pos = start
+		return njson.Node{}, p.Errorf("unsupported tag " + strconv.FormatUint(tag, 10))
+	default:
+		return p.decode(mode)
+	}
+}
+
+func (p *decodeState) simple(start int, info byte, arg uint64) (njson.Node, error) {
+	d := p.doc
+	switch info {
+	case simpleFalse:
+		return d.False(), nil
+	case simpleTrue:
+		return d.True(), nil
+	case simpleNull:
+		return d.Null(), nil
+	case infoUint16:
+		return p.float(float64(fromFloat16(uint16(arg))), 32), nil
+	case infoUint32:
+		return p.float(float64(math.Float32frombits(uint32(arg))), 32), nil
+	case infoUint64:
+		return p.float(math.Float64frombits(arg), 64), nil
+	}
+	if p.DisallowUndefined {
+		p.Pos = start
+		return njson.Node{}, p.Errorf("undefined value")
+	}
+	return d.Null(), nil
+}
+
+func (p *decodeState) float(f float64, bits int) njson.Node {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return p.doc.Null()
+	}
+	return p.doc.NumberRaw(numjson.FormatFloat(f, bits))
+}
+
+// formatNint formats the value of a negative integer -1 - n.
+func formatNint(n uint64) string {
+	if n < math.MaxInt64 {
+		return strconv.FormatInt(-1-int64(n), 10)
+	}
+	b := new(big.Int).SetUint64(n)
+	b.Add(b, big.NewInt(1))
+	return b.Neg(b).String()
+}
+
+// fromFloat16 converts a half precision float to float32.
+func fromFloat16(h uint16) float32 {
+	var (
+		sign = uint32(h&0x8000) << 16
+		exp  = uint32(h>>10) & 0x1f
+		mant = uint32(h & 0x3ff)
+	)
+	switch exp {
+	case 0:
+		// Zero or subnormal
+		f := float32(mant) / (1 << 24)
+		if sign != 0 {
+			return -f
+		}
+		return f
+	case 0x1f:
+		return math.Float32frombits(sign | 0x7f800000 | mant<<13)
+	default:
+		return math.Float32frombits(sign | (exp+127-15)<<23 | mant<<13)
+	}
+}
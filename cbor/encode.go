@@ -0,0 +1,252 @@
+package cbor
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/alxarch/njson"
+	"github.com/alxarch/njson/numjson"
+	"github.com/alxarch/njson/strjson"
+)
+
+// Encoder encodes njson nodes to CBOR.
+type Encoder struct {
+	Deterministic bool // Use core deterministic encoding
+	UnwrapTags    bool // Encode objects of the form {"tag":N,"value":V} as tagged values
+}
+
+// AppendCBOR appends the CBOR encoding of a node to a buffer using the default options.
+func AppendCBOR(dst []byte, n njson.Node) ([]byte, error) {
+	e := Encoder{}
+	return e.AppendCBOR(dst, n)
+}
+
+// AppendCBOR appends the CBOR encoding of a node to a buffer.
+func (e *Encoder) AppendCBOR(dst []byte, n njson.Node) ([]byte, error) {
+	raw, typ := n.Data()
+	switch typ {
+	case njson.TypeNull:
+		return append(dst, majorSimple|simpleNull), nil
+	case njson.TypeBoolean:
+		if raw == "true" {
+			return append(dst, majorSimple|simpleTrue), nil
+		}
+		return append(dst, majorSimple|simpleFalse), nil
+	case njson.TypeString:
+		s := strjson.Unescaped(raw)
+		dst = appendHead(dst, majorText, uint64(len(s)))
+		return append(dst, s...), nil
+	case njson.TypeNumber:
+		return e.appendNumber(dst, raw)
+	case njson.TypeArray:
+		values := n.Values()
+		dst = appendHead(dst, majorArray, uint64(values.Len()))
+		var err error
+		for values.Next() {
+			if dst, err = e.AppendCBOR(dst, values.Value()); err != nil {
+				return dst, err
+			}
+		}
+		return dst, nil
+	case njson.TypeObject:
+		if e.UnwrapTags {
+			if tag, v, ok := unwrapTag(n); ok {
+				dst = appendHead(dst, majorTag, tag)
+				return e.AppendCBOR(dst, v)
+			}
+		}
+		if e.Deterministic {
+			return e.appendSortedMap(dst, n)
+		}
+		values := n.Values()
+		dst = appendHead(dst, majorMap, uint64(values.Len()))
+		var err error
+		for values.Next() {
+			key := strjson.Unescaped(values.Key())
+			dst = appendHead(dst, majorText, uint64(len(key)))
+			dst = append(dst, key...)
+			if dst, err = e.AppendCBOR(dst, values.Value()); err != nil {
+				return dst, err
+			}
+		}
+		return dst, nil
+	default:
+		return dst, n.TypeError(njson.TypeAnyValue)
+	}
+}
+
+// unwrapTag checks if an object node is of the form {"tag":N,"value":V}.
+func unwrapTag(n njson.Node) (uint64, njson.Node, bool) {
+	if values := n.Values(); values.Len() != 2 {
+		return 0, njson.Node{}, false
+	}
+	v := n.Get(keyValue)
+	if v.Type() == njson.TypeInvalid {
+		return 0, njson.Node{}, false
+	}
+	tag, ok := n.Get(keyTag).ToUint()
+	return tag, v, ok
+}
+
+// entry holds the offsets of an encoded map entry in a buffer.
+type entry struct {
+	start, mid, end int
+}
+
+func (e *Encoder) appendSortedMap(dst []byte, n njson.Node) ([]byte, error) {
+	var (
+		values  = n.Values()
+		entries = make([]entry, 0, values.Len())
+		buf     []byte
+		err     error
+	)
+	for values.Next() {
+		key := strjson.Unescaped(values.Key())
+		start := len(buf)
+		buf = appendHead(buf, majorText, uint64(len(key)))
+		buf = append(buf, key...)
+		mid := len(buf)
+		if buf, err = e.AppendCBOR(buf, values.Value()); err != nil {
+			return dst, err
+		}
+		entries = append(entries, entry{start, mid, len(buf)})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		a, b := &entries[i], &entries[j]
+		return bytes.Compare(buf[a.start:a.mid], buf[b.start:b.mid]) < 0
+	})
+	dst = appendHead(dst, majorMap, uint64(len(entries)))
+	for i := range entries {
+		ent := &entries[i]
+		if i > 0 {
+			prev := &entries[i-1]
+			if bytes.Equal(buf[prev.start:prev.mid], buf[ent.start:ent.mid]) {
+				return dst, fmt.Errorf("Duplicate map key %q", buf[ent.start:ent.mid])
+			}
+		}
+		dst = append(dst, buf[ent.start:ent.end]...)
+	}
+	return dst, nil
+}
+
+func (e *Encoder) appendNumber(dst []byte, raw string) ([]byte, error) {
+	if strings.IndexAny(raw, ".eE") == -1 {
+		if u, err := strconv.ParseUint(raw, 10, 64); err == nil {
+			return appendHead(dst, majorUint, u), nil
+		}
+		if strings.HasPrefix(raw, "-") {
+			if u, err := strconv.ParseUint(raw[1:], 10, 64); err == nil {
+				if u == 0 {
+					return appendHead(dst, majorUint, 0), nil
+				}
+				return appendHead(dst, majorNint, u-1), nil
+			}
+		}
+		b, ok := new(big.Int).SetString(raw, 10)
+		if !ok {
+			return dst, fmt.Errorf("%w %q", numjson.ErrInvalidNumber, raw)
+		}
+		tag := uint64(tagPositiveBignum)
+		if b.Sign() < 0 {
+			// Negative bignums encode -1 - n
+			tag = tagNegativeBignum
+			b.Neg(b)
+			b.Sub(b, big.NewInt(1))
+		}
+		data := b.Bytes()
+		dst = appendHead(dst, majorTag, tag)
+		dst = appendHead(dst, majorBytes, uint64(len(data)))
+		return append(dst, data...), nil
+	}
+	f, err := numjson.Parse(raw)
+	if err != nil {
+		return dst, err
+	}
+	return e.appendFloat(dst, f), nil
+}
+
+func (e *Encoder) appendFloat(dst []byte, f float64) []byte {
+	if e.Deterministic {
+		if f32 := float32(f); float64(f32) == f {
+			if f16, ok := toFloat16(f32); ok {
+				return append(dst, majorSimple|infoUint16, byte(f16>>8), byte(f16))
+			}
+			dst = append(dst, majorSimple|infoUint32)
+			return appendUint32(dst, math.Float32bits(f32))
+		}
+	}
+	dst = append(dst, majorSimple|infoUint64)
+	return appendUint64(dst, math.Float64bits(f))
+}
+
+// appendHead appends the initial bytes of a data item using the shortest form.
+func appendHead(dst []byte, major byte, n uint64) []byte {
+	switch {
+	case n < infoUint8:
+		return append(dst, major|byte(n))
+	case n <= math.MaxUint8:
+		return append(dst, major|infoUint8, byte(n))
+	case n <= math.MaxUint16:
+		return append(dst, major|infoUint16, byte(n>>8), byte(n))
+	case n <= math.MaxUint32:
+		dst = append(dst, major|infoUint32)
+		return appendUint32(dst, uint32(n))
+	default:
+		dst = append(dst, major|infoUint64)
+		return appendUint64(dst, n)
+	}
+}
+
+func appendUint32(dst []byte, n uint32) []byte {
+	var buf [4]byte
+	binary.BigEndian.PutUint32(buf[:], n)
+	return append(dst, buf[:]...)
+}
+
+func appendUint64(dst []byte, n uint64) []byte {
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], n)
+	return append(dst, buf[:]...)
+}
+
+// toFloat16 converts a float32 to half precision if it can be done without loss.
+func toFloat16(f float32) (uint16, bool) {
+	var (
+		bits = math.Float32bits(f)
+		sign = uint16(bits>>16) & 0x8000
+		exp  = int(bits>>23&0xff) - 127
+		mant = bits & 0x7fffff
+	)
+	switch {
+	case exp == 128:
+		// Inf or NaN
+		if mant == 0 {
+			return sign | 0x7c00, true
+		}
+		return 0x7e00, true
+	case exp == -127:
+		// Zero or float32 subnormal
+		return sign, mant == 0
+	case -14 <= exp && exp <= 15:
+		if mant&0x1fff != 0 {
+			return 0, false
+		}
+		return sign | uint16(exp+15)<<10 | uint16(mant>>13), true
+	case -24 <= exp && exp < -14:
+		// Half precision subnormal
+		shift := uint(-14-exp) + 13
+		full := 1<<23 | mant
+		if full&(1<<shift-1) != 0 {
+			return 0, false
+		}
+		return sign | uint16(full>>shift), true
+	default:
+		return 0, false
+	}
+}
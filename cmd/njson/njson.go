@@ -25,7 +25,7 @@ var (
 	generateAppend    = flag.Bool("append", false, "Generate AppendJSON methods.")
 	generateUnmarshal = flag.Bool("unmarshal", false, "Generate UnmarshalNodeJSON methods.")
 	matchFieldNames   = flag.String("match", ".*", "Regex for filtering by field name.")
-	caseTransform     = flag.String("case", "none", "Field name case transformation for untagged fields (none|snake|camel|lower).")
+	caseTransform     = flag.String("case", "none", "Field name case transformation for untagged fields (none|snake|camel|Camel|lower).")
 	writeFile         = flag.Bool("w", false, `Write output to a file named "{pkgname}_njson.go".`)
 	debug             = flag.Bool("d", false, "Debug mode.")
 	// tests           = flag.Bool("tests", false, "Write test methods.")
@@ -1,6 +1,14 @@
 package njson
 
 import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
 	"sync"
 
 	"github.com/alxarch/njson/strjson"
@@ -11,7 +19,8 @@ import (
 // Document is a JSON document.
 type Document struct {
 	nodes []node
-	rev   uint // document revision incremented on every Reset/Close invalidating nodes
+	rev   uint   // document revision incremented on every Reset/Close invalidating nodes
+	spans []span // input spans of nodes recorded by ParseSpans
 }
 
 // node is a JSON document node.
@@ -57,6 +66,69 @@ func (n *node) reset(inf info, raw string, values []V) {
 	}
 }
 
+// findKey returns the index of a key in an object's values or -1.
+// Raw keys containing escapes are compared in unescaped form.
+func findKey(values []V, key string) int {
+	for i := range values {
+		if values[i].key == key {
+			return i
+		}
+	}
+	// Slow path for keys with escapes only if the key was not found
+	for i := range values {
+		if raw := values[i].key; strings.IndexByte(raw, delimEscape) != -1 && len(key) <= len(raw) {
+			if unescapedEqual(raw, key) {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// unescapedEqual compares the unescaped form of a raw key to key.
+// It unescapes one escape sequence at a time so it does not allocate.
+func unescapedEqual(raw, key string) bool {
+	var buf [12]byte
+	for {
+		i := strings.IndexByte(raw, delimEscape)
+		if i == -1 {
+			return raw == key
+		}
+		if len(key) < i || raw[:i] != key[:i] {
+			return false
+		}
+		raw, key = raw[i:], key[i:]
+		size := escapeSize(raw)
+		n := strjson.Unescape(buf[:], raw[:size])
+		if len(key) < n {
+			return false
+		}
+		for j := 0; j < n; j++ {
+			if buf[j] != key[j] {
+				return false
+			}
+		}
+		raw, key = raw[size:], key[n:]
+	}
+}
+
+// escapeSize returns the size of the escape sequence at the start of s.
+// A surrogate pair is a single sequence.
+func escapeSize(s string) int {
+	switch {
+	case len(s) < 2:
+		return len(s)
+	case s[1] != 'u':
+		return 2
+	case len(s) < 6:
+		return len(s)
+	case len(s) >= 12 && (s[2] == 'd' || s[2] == 'D') && strings.IndexByte("89abAB", s[3]) != -1 && s[6:8] == "\\u":
+		return 12
+	default:
+		return 6
+	}
+}
+
 // lookup finds a node's id by path.
 func (d *Document) lookup(id uint, path []string) uint {
 	var (
@@ -68,12 +140,9 @@ lookup:
 		if n = d.get(id); n != nil {
 			switch n.info.Type() {
 			case TypeObject:
-				for i := range n.values {
-					v = &n.values[i]
-					if v.key == key {
-						id = v.id
-						continue lookup
-					}
+				if i := findKey(n.values, key); 0 <= i && i < len(n.values) {
+					id = n.values[i].id
+					continue lookup
 				}
 			case TypeArray:
 				i := 0
@@ -163,9 +232,19 @@ func (d *Document) Number(f float64) Node {
 	return d.Node(id)
 }
 
+// NumberRaw adds a new Number node to the document without checking the number syntax.
+// Unless the provided string is a valid JSON number, JSON output from this Node will be invalid.
+func (d *Document) NumberRaw(s string) Node {
+	id := uint(len(d.nodes))
+	n := d.grow()
+	n.reset(vNumber|infRoot, s, n.values[:0])
+	return d.Node(id)
+}
+
 // Reset resets the document to empty.
 func (d *Document) Reset() {
 	d.nodes = d.nodes[:0]
+	d.spans = d.spans[:0]
 	// Invalidate any partials
 	d.rev++
 }
@@ -173,7 +252,10 @@ func (d *Document) Reset() {
 // get finds a node by id.
 func (d *Document) get(id uint) *node {
 	if d != nil && id < uint(len(d.nodes)) {
-		return &d.nodes[id]
+		if n := &d.nodes[id]; n.info&infLazy == 0 {
+			return n
+		}
+		return d.expand(id)
 	}
 	return nil
 }
@@ -188,17 +270,46 @@ func (d *Document) Root() Node {
 	return Node{0, d.rev, d}
 }
 
+// InterfaceOptions are options for converting a Node to a generic interface{}.
+type InterfaceOptions struct {
+	UseNumber      bool // Convert numbers to json.Number. It takes precedence over Integers.
+	Integers       bool // Convert integral numbers to int64 or to *big.Int if they overflow int64.
+	OrderedObjects bool // Convert objects to Members preserving key order.
+}
+
+// Member is a key/value pair of an object.
+type Member struct {
+	Key   string
+	Value interface{}
+}
+
+// Members is an ordered list of object key/value pairs.
+type Members []Member
+
+// Get returns the value of the first member with the key.
+func (m Members) Get(key string) (interface{}, bool) {
+	for i := range m {
+		if m[i].Key == key {
+			return m[i].Value, true
+		}
+	}
+	return nil, false
+}
+
 // toInterface converts a node to any combatible go value (many allocations on large trees).
-func (d *Document) toInterface(id uint) (interface{}, bool) {
+func (d *Document) toInterface(id uint, opts *InterfaceOptions) (interface{}, bool) {
 	n := d.get(id)
 	if n == nil {
 		return nil, false
 	}
 	switch n.info.Type() {
 	case TypeObject:
-		return d.toInterfaceMap(n.values)
+		if opts.OrderedObjects {
+			return d.toInterfaceMembers(n.values, opts)
+		}
+		return d.toInterfaceMap(n.values, opts)
 	case TypeArray:
-		return d.toInterfaceSlice(n.values)
+		return d.toInterfaceSlice(n.values, opts)
 	case TypeString:
 		return strjson.Unescaped(n.raw), true
 	case TypeBoolean:
@@ -213,6 +324,14 @@ func (d *Document) toInterface(id uint) (interface{}, bool) {
 	case TypeNull:
 		return nil, true
 	case TypeNumber:
+		if opts.UseNumber {
+			return json.Number(n.raw), true
+		}
+		if opts.Integers {
+			if x, ok := toInteger(n.raw); ok {
+				return x, true
+			}
+		}
 		f := numjson.ParseFloat(n.raw)
 		return f, f == f
 	default:
@@ -220,13 +339,50 @@ func (d *Document) toInterface(id uint) (interface{}, bool) {
 	}
 }
 
-func (d *Document) toInterfaceMap(values []V) (interface{}, bool) {
+// toInteger converts an integral number to int64 or *big.Int.
+func toInteger(raw string) (interface{}, bool) {
+	if strings.IndexAny(raw, ".eE") == -1 {
+		if i, err := strconv.ParseInt(raw, 10, 64); err == nil {
+			return i, true
+		}
+		if b, ok := new(big.Int).SetString(raw, 10); ok {
+			return b, true
+		}
+		return nil, false
+	}
+	r, ok := new(big.Rat).SetString(raw)
+	if !ok || !r.IsInt() {
+		return nil, false
+	}
+	num := r.Num()
+	if num.IsInt64() {
+		return num.Int64(), true
+	}
+	return num, true
+}
+
+func (d *Document) toInterfaceMap(values []V, opts *InterfaceOptions) (interface{}, bool) {
 	var (
 		m  = make(map[string]interface{}, len(values))
 		ok bool
 	)
 	for _, v := range values {
-		m[v.key], ok = d.toInterface(v.id)
+		m[v.key], ok = d.toInterface(v.id, opts)
+		if !ok {
+			return nil, false
+		}
+	}
+	return m, true
+}
+
+func (d *Document) toInterfaceMembers(values []V, opts *InterfaceOptions) (Members, bool) {
+	var (
+		m  = make(Members, len(values))
+		ok bool
+	)
+	for i, v := range values {
+		m[i].Key = strjson.Unescaped(v.key)
+		m[i].Value, ok = d.toInterface(v.id, opts)
 		if !ok {
 			return nil, false
 		}
@@ -234,13 +390,13 @@ func (d *Document) toInterfaceMap(values []V) (interface{}, bool) {
 	return m, true
 }
 
-func (d *Document) toInterfaceSlice(values []V) ([]interface{}, bool) {
+func (d *Document) toInterfaceSlice(values []V, opts *InterfaceOptions) ([]interface{}, bool) {
 	var (
 		x  = make([]interface{}, len(values))
 		ok bool
 	)
 	for i, v := range values {
-		x[i], ok = d.toInterface(v.id)
+		x[i], ok = d.toInterface(v.id, opts)
 		if !ok {
 			return nil, false
 		}
@@ -248,8 +404,152 @@ func (d *Document) toInterfaceSlice(values []V) ([]interface{}, bool) {
 	return x, true
 }
 
+// FromInterface adds a new node to the document converting a generic Go value.
+//
+// Supported values are nil, bool, string, all integer and float types, json.Number,
+// json.RawMessage, Node, []interface{}, []string, map[string]interface{} and map[string]string.
+// Strings and keys are escaped with strjson.Escaped and floats are formatted with numjson.FormatFloat.
+// Object keys are sorted to produce deterministic output.
+func (d *Document) FromInterface(x interface{}) (Node, error) {
+	id, err := d.fromInterface(x)
+	if err != nil {
+		return Node{}, err
+	}
+	d.nodes[id].info |= infRoot
+	return d.Node(id), nil
+}
+
+// add adds a new non-root node to the document.
+func (d *Document) add(inf info, raw string) uint {
+	id := uint(len(d.nodes))
+	n := d.grow()
+	n.reset(inf, raw, n.values[:0])
+	return id
+}
+
+func (d *Document) fromInterface(x interface{}) (uint, error) {
+	switch x := x.(type) {
+	case nil:
+		return d.add(vNull, strNull), nil
+	case bool:
+		if x {
+			return d.add(vBoolean, strTrue), nil
+		}
+		return d.add(vBoolean, strFalse), nil
+	case string:
+		return d.add(vString, strjson.Escaped(x, false, false)), nil
+	case float64:
+		return d.fromFloat(x, 64)
+	case float32:
+		return d.fromFloat(float64(x), 32)
+	case int:
+		return d.add(vNumber, strconv.FormatInt(int64(x), 10)), nil
+	case int8:
+		return d.add(vNumber, strconv.FormatInt(int64(x), 10)), nil
+	case int16:
+		return d.add(vNumber, strconv.FormatInt(int64(x), 10)), nil
+	case int32:
+		return d.add(vNumber, strconv.FormatInt(int64(x), 10)), nil
+	case int64:
+		return d.add(vNumber, strconv.FormatInt(x, 10)), nil
+	case uint:
+		return d.add(vNumber, strconv.FormatUint(uint64(x), 10)), nil
+	case uint8:
+		return d.add(vNumber, strconv.FormatUint(uint64(x), 10)), nil
+	case uint16:
+		return d.add(vNumber, strconv.FormatUint(uint64(x), 10)), nil
+	case uint32:
+		return d.add(vNumber, strconv.FormatUint(uint64(x), 10)), nil
+	case uint64:
+		return d.add(vNumber, strconv.FormatUint(x, 10)), nil
+	case json.Number:
+		if _, err := numjson.Parse(string(x)); err != nil {
+			return maxUint, err
+		}
+		return d.add(vNumber, string(x)), nil
+	case json.RawMessage:
+		n, tail, err := d.Parse(string(x))
+		if err != nil {
+			return maxUint, err
+		}
+		if strings.TrimSpace(tail) != "" {
+			return maxUint, fmt.Errorf("Invalid raw message tail %q", tail)
+		}
+		d.nodes[n.id].info &^= infRoot
+		return n.id, nil
+	case Node:
+		if n := x.get(); n != nil {
+			return d.ncopy(x.doc, n), nil
+		}
+		return maxUint, newTypeError(TypeInvalid, TypeAnyValue)
+	case []interface{}:
+		id := d.add(vArray, "")
+		values := d.nodes[id].values[:0]
+		for _, x := range x {
+			v, err := d.fromInterface(x)
+			if err != nil {
+				return maxUint, err
+			}
+			values = append(values, V{v, ""})
+		}
+		d.nodes[id].values = values
+		return id, nil
+	case []string:
+		id := d.add(vArray, "")
+		values := d.nodes[id].values[:0]
+		for _, s := range x {
+			values = append(values, V{d.add(vString, strjson.Escaped(s, false, false)), ""})
+		}
+		d.nodes[id].values = values
+		return id, nil
+	case map[string]interface{}:
+		keys := make([]string, 0, len(x))
+		for k := range x {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		id := d.add(vObject, "")
+		values := d.nodes[id].values[:0]
+		for _, k := range keys {
+			v, err := d.fromInterface(x[k])
+			if err != nil {
+				return maxUint, err
+			}
+			values = append(values, V{v, strjson.Escaped(k, false, false)})
+		}
+		d.nodes[id].values = values
+		return id, nil
+	case map[string]string:
+		keys := make([]string, 0, len(x))
+		for k := range x {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		id := d.add(vObject, "")
+		values := d.nodes[id].values[:0]
+		for _, k := range keys {
+			v := d.add(vString, strjson.Escaped(x[k], false, false))
+			values = append(values, V{v, strjson.Escaped(k, false, false)})
+		}
+		d.nodes[id].values = values
+		return id, nil
+	default:
+		return maxUint, &UnsupportedTypeError{reflect.TypeOf(x)}
+	}
+}
+
+func (d *Document) fromFloat(f float64, bits int) (uint, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return maxUint, fmt.Errorf("%w %v", numjson.ErrInvalidNumber, f)
+	}
+	return d.add(vNumber, numjson.FormatFloat(f, bits)), nil
+}
+
 // AppendJSON appends the JSON data of the document root node to a byte slice.
 func (d *Document) AppendJSON(dst []byte) ([]byte, error) {
+	if len(d.spans) != 0 {
+		return d.appendSource(dst, 0)
+	}
 	return d.appendJSON(dst, d.get(0))
 }
 
@@ -258,6 +558,12 @@ func (d *Document) appendJSON(dst []byte, n *node) ([]byte, error) {
 		return dst, newTypeError(TypeInvalid, TypeAnyValue)
 	}
 	switch n.info.Type() {
+	case TypeInvalid:
+		// Lazy nodes with invalid values
+		if err := n.err(); err != nil {
+			return dst, err
+		}
+		return dst, newTypeError(TypeInvalid, TypeAnyValue)
 	case TypeObject:
 		dst = append(dst, delimBeginObject)
 		var err error
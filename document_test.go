@@ -1,6 +1,8 @@
 package njson
 
 import (
+	"encoding/json"
+	"math"
 	"strings"
 	"testing"
 )
@@ -78,6 +80,12 @@ func TestDocument_Create(t *testing.T) {
 		raw:    `42`,
 		values: nil,
 	})
+	n = d.NumberRaw("1e400")
+	assertEqual(t, n.get(), &node{
+		info:   vNumber | infRoot,
+		raw:    `1e400`,
+		values: nil,
+	})
 	n = d.Array()
 	assertEqual(t, n.get(), &node{
 		info:   vArray | infRoot,
@@ -135,3 +143,44 @@ func TestDocument_ncopy(t *testing.T) {
 	assertEqual(t, string(data), `{"foo":"bar","bar":{}}`)
 
 }
+
+func TestDocument_FromInterface(t *testing.T) {
+	d := Document{}
+	other := Document{}
+	n, _, err := other.Parse(`{"foo":"bar"}`)
+	assertNoError(t, err)
+	x := map[string]interface{}{
+		"string": "foo\n",
+		"int":    -42,
+		"uint":   uint8(42),
+		"float":  1.5,
+		"bool":   true,
+		"null":   nil,
+		"number": json.Number("1e3"),
+		"raw":    json.RawMessage(` [1, {"a": null}] `),
+		"node":   n,
+		"array":  []interface{}{"a", 1.0, false},
+		"tags":   []string{"x", "y"},
+		"map":    map[string]string{"é": "<p>"},
+	}
+	root, err := d.FromInterface(x)
+	assertNoError(t, err)
+	assertEqual(t, root.get().info.IsRoot(), true)
+	data, err := root.AppendJSON(nil)
+	assertNoError(t, err)
+	assertEqual(t, string(data), `{"array":["a",1,false],"bool":true,"float":1.5,"int":-42,"map":{"é":"<p>"},"node":{"foo":"bar"},"null":null,"number":1e3,"raw":[1,{"a":null}],"string":"foo\n","tags":["x","y"],"uint":42}`)
+	assertEqual(t, root.Lookup("raw", "1").get().info.IsRoot(), false)
+
+	for _, x := range []interface{}{
+		struct{}{},
+		math.NaN(),
+		json.Number("foo"),
+		json.RawMessage(`[1,`),
+		json.RawMessage(`1 2`),
+		[]interface{}{make(chan int)},
+	} {
+		if _, err := d.FromInterface(x); err == nil {
+			t.Errorf("Expected error for %v", x)
+		}
+	}
+}
@@ -1,22 +1,62 @@
 package njson
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
 
-type typeError struct {
-	Type Type
-	Want Type
+// ErrMissingValue is the error for values not found at a path.
+var ErrMissingValue = errors.New("Missing value")
+
+// TypeError signifies a JSON value type mismatch.
+type TypeError struct {
+	Type Type     // Actual type of the value
+	Want Type     // Wanted type mask
+	Path []string // Path of the value if known
 }
 
 // newTypeError returns a type mismatch error.
 func newTypeError(t, want Type) error {
-	return typeError{t, want}
+	return &TypeError{Type: t, Want: want}
 }
 
-func (e typeError) Error() string {
+func (e *TypeError) Error() string {
+	var msg string
 	if e.Want&e.Type != 0 {
-		return fmt.Sprintf("Invalid value for type %s", e.Type)
+		msg = fmt.Sprintf("Invalid value for type %s", e.Type)
+	} else {
+		msg = fmt.Sprintf("Invalid type %s not in %v", e.Type, e.Want.Types())
+	}
+	if len(e.Path) > 0 {
+		return fmt.Sprintf("%s at %q", msg, strings.Join(e.Path, "."))
 	}
-	return fmt.Sprintf("Invalid type %s not in %v", e.Type, e.Want.Types())
+	return msg
+}
+
+// PathError wraps an error with the path of the value that caused it.
+type PathError struct {
+	Path []string
+	Err  error
+}
+
+func (e *PathError) Error() string {
+	return fmt.Sprintf("%s at %q", e.Err, strings.Join(e.Path, "."))
+}
+
+// Unwrap returns the underlying error.
+func (e *PathError) Unwrap() error {
+	return e.Err
+}
+
+// UnsupportedTypeError signifies a Go type that cannot be converted to or from JSON.
+type UnsupportedTypeError struct {
+	Type reflect.Type
+}
+
+func (e *UnsupportedTypeError) Error() string {
+	return fmt.Sprintf("Unsupported value type %v", e.Type)
 }
 
 // ParseError signifies an invalid token in JSON data
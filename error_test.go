@@ -1,7 +1,12 @@
 package njson
 
 import (
+	"encoding/json"
+	"errors"
+	"reflect"
 	"testing"
+
+	"github.com/alxarch/njson/numjson"
 )
 
 func TestTypeError(t *testing.T) {
@@ -22,3 +27,29 @@ func Test_ParseError(t *testing.T) {
 	assertEqual(t, err.Error(), "Invalid token '?' != ['\"' '}'] at position 2 while scanning String")
 
 }
+
+func TestErrorsAs(t *testing.T) {
+	d := Document{}
+	n, _, err := d.Parse(`{"foo":{"bar":1}}`)
+	assertNoError(t, err)
+	var typeErr *TypeError
+	_, err = n.GetString("foo", "bar")
+	assert(t, errors.As(err, &typeErr), "Invalid error %v", err)
+	assertEqual(t, typeErr, &TypeError{TypeNumber, TypeString, []string{"foo", "bar"}})
+	assertEqual(t, err.Error(), `Invalid type Number not in [String] at "foo.bar"`)
+
+	var pathErr *PathError
+	_, err = n.GetString("foo", "baz")
+	assert(t, errors.As(err, &pathErr), "Invalid error %v", err)
+	assertEqual(t, pathErr.Path, []string{"foo", "baz"})
+	assert(t, errors.Is(err, ErrMissingValue), "Invalid error %v", err)
+
+	var unsupportedErr *UnsupportedTypeError
+	_, err = d.FromInterface(struct{}{})
+	assert(t, errors.As(err, &unsupportedErr), "Invalid error %v", err)
+	assertEqual(t, unsupportedErr.Type, reflect.TypeOf(struct{}{}))
+	assertEqual(t, err.Error(), "Unsupported value type struct {}")
+
+	_, err = d.FromInterface(json.Number("foo"))
+	assert(t, errors.Is(err, numjson.ErrInvalidNumber), "Invalid error %v", err)
+}
@@ -0,0 +1,122 @@
+// Package codec holds the decoding state shared by the binary format packages.
+//
+// It does not import njson so that njson can import it and set ObjectBuilder's
+// hooks to methods it keeps unexported.
+package codec
+
+import "fmt"
+
+// DefaultMaxDepth is the maximum nesting depth of values if a decoder does not set one.
+const DefaultMaxDepth = 10000
+
+// DecodeError signifies invalid data.
+type DecodeError struct {
+	format string
+	pos    int
+	msg    string
+}
+
+// Pos returns the offset at which the error ocurred.
+func (e *DecodeError) Pos() int {
+	return e.pos
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("Invalid %s data at position %d: %s", e.format, e.pos, e.msg)
+}
+
+// State is the position and nesting depth of a decoder.
+type State struct {
+	Format string // Name of the data format in error messages
+	Pos    int    // Offset of the next byte to decode
+	depth  int
+}
+
+// Errorf returns a DecodeError at the current position.
+func (s *State) Errorf(msg string) error {
+	return &DecodeError{format: s.Format, pos: s.Pos, msg: msg}
+}
+
+// EOF returns a DecodeError for truncated data.
+func (s *State) EOF() error {
+	return s.Errorf("unexpected end of data")
+}
+
+// Enter increments the nesting depth checking the limit.
+// If max is not positive DefaultMaxDepth is used.
+func (s *State) Enter(max int) error {
+	if max <= 0 {
+		max = DefaultMaxDepth
+	}
+	if s.depth++; s.depth > max {
+		return s.Errorf("maximum nesting depth exceeded")
+	}
+	return nil
+}
+
+// Leave decrements the nesting depth.
+func (s *State) Leave() {
+	s.depth--
+}
+
+// Hooks set by package njson.
+// Doc is a *njson.Document and the ids are ids of its nodes.
+var (
+	// SetValue sets a key of an object node replacing an existing key.
+	SetValue func(doc interface{}, id uint, key string, value uint)
+	// AddValue appends a key to an object node without checking for an existing key.
+	AddValue func(doc interface{}, id uint, key string, value uint)
+)
+
+// smallObject is the number of keys below which duplicate keys are found by scanning.
+const smallObject = 16
+
+// ObjectBuilder adds decoded map entries to an object node.
+// Duplicate keys keep the last value. Once an object grows beyond smallObject keys
+// it tracks keys in a set so that decoding large maps does not take quadratic time.
+type ObjectBuilder struct {
+	doc   interface{}
+	id    uint
+	size  int
+	small [smallObject]string
+	keys  map[string]struct{}
+}
+
+// NewObjectBuilder returns a builder for the object node id of doc.
+func NewObjectBuilder(doc interface{}, id uint) ObjectBuilder {
+	return ObjectBuilder{doc: doc, id: id}
+}
+
+// Set adds a key to the object replacing the value of a duplicate key.
+func (b *ObjectBuilder) Set(key string, value uint) {
+	if b.seen(key) {
+		SetValue(b.doc, b.id, key, value)
+	} else {
+		AddValue(b.doc, b.id, key, value)
+	}
+}
+
+// seen records a key and reports whether it was added before.
+func (b *ObjectBuilder) seen(key string) bool {
+	if b.keys == nil {
+		for _, k := range b.small[:b.size] {
+			if k == key {
+				return true
+			}
+		}
+		if b.size < smallObject {
+			b.small[b.size] = key
+			b.size++
+			return false
+		}
+		b.keys = make(map[string]struct{}, 2*smallObject)
+		for _, k := range b.small {
+			b.keys[k] = struct{}{}
+		}
+	}
+	if _, duplicate := b.keys[key]; duplicate {
+		return true
+	}
+	b.keys[key] = struct{}{}
+	return false
+}
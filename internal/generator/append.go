@@ -121,19 +121,28 @@ func (g *Generator) EnsureReversePath(path meta.FieldPath, code meta.Code) meta.
 func (g *Generator) StructAppender(fields meta.Fields) (c meta.Code) {
 	c = c.Println(`more := 0`)
 	sortedFields := []meta.Field{}
-	for name := range fields {
+	for _, name := range fieldNames(fields) {
 		sortedFields = append(sortedFields, fields[name]...)
 	}
+	// Write fields in declaration order like unjson does
 	sort.SliceStable(sortedFields, func(i, j int) bool {
-		return sortedFields[i].Tag < sortedFields[j].Tag
+		return declaredBefore(sortedFields[i].Path, sortedFields[j].Path)
 	})
 	used := make(map[string]bool)
+	var remain *meta.Field
 	for _, field := range sortedFields {
 		field = field.WithTag(g.TagKey())
 		name, tag, ok := g.parseField(field.Var, field.Tag)
 		if !ok {
 			continue
 		}
+		if isRemain(tag) {
+			if remain == nil || meta.ShortestPath(field.Path, remain.Path) == -1 {
+				f := field
+				remain = &f
+			}
+			continue
+		}
 		if tag.Name != "" {
 			name = tag.Name
 		}
@@ -159,11 +168,78 @@ func (g *Generator) StructAppender(fields meta.Fields) (c meta.Code) {
 		c = c.Append(cf)
 
 	}
+	if remain != nil {
+		cf := g.RemainAppender(remain.Type(), used)
+		c = c.Append(g.EnsureReversePath(remain.Path, cf))
+	}
 	c = c.Println(`
 	out = append(out, "{}"[more:]...)`)
 	return
 }
 
+// RemainAppender returns the AppendJSON block code for a field holding the object keys not matching any other field.
+func (g *Generator) RemainAppender(typ types.Type, used map[string]bool) (c meta.Code) {
+	known := make([]string, 0, len(used))
+	for name := range used {
+		known = append(known, fmt.Sprintf("%q", name))
+	}
+	sort.Strings(known)
+	skip := ""
+	if len(known) > 0 {
+		skip = fmt.Sprintf("switch k { case %s: continue }", strings.Join(known, ", "))
+	}
+	switch t := typ.Underlying().(type) {
+	case *types.Map:
+		return g.Code(`
+			for k, v := range v {
+				%s
+				out = append(out, "{,"[more])
+				more = 1
+				out = append(out, '"')
+				out = strjson.AppendEscaped(out, string(k), false)
+				out = append(out, '"', ':')
+				%s
+			}
+		`, skip, g.TypeAppender(t.Elem(), nil)).Import(strjsonPkg)
+	case *types.Slice:
+		if b, ok := t.Elem().Underlying().(*types.Basic); ok && b.Kind() == types.Byte {
+			return g.Code(`
+				if raw := bytes.TrimSpace(v); len(raw) > 0 {
+					doc := njson.Blank()
+					defer doc.Close()
+					n, tail, err := doc.Parse(string(raw))
+					if err != nil || tail != "" || n.Type() != njson.TypeObject {
+						return out, errors.New("Invalid remain field value")
+					}
+					for values := n.Values(); values.Next(); {
+						k := values.Key()
+						%s
+						out = append(out, "{,"[more])
+						more = 1
+						out = append(out, '"')
+						out = append(out, k...)
+						out = append(out, '"', ':')
+						if out, err = values.Value().AppendJSON(out); err != nil {
+							return out, err
+						}
+					}
+				}
+			`, skip).Import(bytesPkg, errorsPkg, njsonPkg)
+		}
+	}
+	return c.Errorf("Invalid remain field type %s", typ)
+}
+
+// declaredBefore compares the struct field indexes of two field paths depth first.
+func declaredBefore(a, b meta.FieldPath) bool {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i].Index != b[i].Index {
+			return a[i].Index < b[i].Index
+		}
+	}
+	return len(a) < len(b)
+}
+
 // TypeAppender returnds the AppendJSON block code for a type
 func (g *Generator) TypeAppender(typ types.Type, params meta.Params) (c meta.Code) {
 	switch {
@@ -200,6 +276,7 @@ func (g *Generator) TypeAppender(typ types.Type, params meta.Params) (c meta.Cod
 			if v == nil {
 				out = append(out, "null"...)
 			} else {
+				v := *v
 				%s
 			}
 		`, g.TypeAppender(t.Elem(), params))
@@ -236,6 +313,13 @@ func (g *Generator) TypeAppender(typ types.Type, params meta.Params) (c meta.Cod
 		fields := meta.NewFields(t, true)
 		return g.StructAppender(fields)
 	case *types.Basic:
+		if params.Has(paramString) && t.Kind() != types.String {
+			return g.Code(`
+				out = append(out, '"')
+				%s
+				out = append(out, '"')
+			`, g.TypeAppender(typ, nil))
+		}
 		switch t.Kind() {
 		case types.Bool:
 			return c.Println(`if v { out = append(out, "true"...) } else { out = append(out, "false"...) }`)
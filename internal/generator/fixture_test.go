@@ -0,0 +1,96 @@
+package generator
+
+import (
+	"bytes"
+	"flag"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+// fixtureOptions are the generator options for each fixture file
+var fixtureOptions = map[string][]Option{
+	"case.go": {TransformFieldCase("snake")},
+}
+
+// TestGenerator_Fixtures generates code for all structs in testdata/fixtures
+// and compares it to the golden files after checking that it compiles.
+func TestGenerator_Fixtures(t *testing.T) {
+	filenames, err := filepath.Glob(filepath.Join("testdata", "fixtures", "*.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(filenames) == 0 {
+		t.Fatal("No fixtures found")
+	}
+	for _, filename := range filenames {
+		filename := filename
+		t.Run(filepath.Base(filename), func(t *testing.T) {
+			src, err := ioutil.ReadFile(filename)
+			if err != nil {
+				t.Fatal(err)
+			}
+			name := filepath.Base(filename)
+			g, err := NewFromFile(name, src, fixtureOptions[name]...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, name := range g.AllStructs() {
+				if err := g.WriteUnmarshaler(name); err != nil {
+					t.Fatal(err)
+				}
+				if err := g.WriteAppender(name); err != nil {
+					t.Fatal(err)
+				}
+			}
+			buf := new(bytes.Buffer)
+			if err := g.PrintTo(buf); err != nil {
+				t.Fatal(err)
+			}
+			// Skip the header with the generation date
+			out := buf.String()
+			out = out[strings.IndexByte(out, '\n')+1:]
+			checkFixture(t, filename, src, out)
+
+			golden := strings.TrimSuffix(filename, ".go") + ".golden"
+			if *update {
+				if err := ioutil.WriteFile(golden, []byte(out), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := ioutil.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if out != string(want) {
+				t.Errorf("Generated code does not match %s, run with -update to see the diff in git\n%s", golden, out)
+			}
+		})
+	}
+}
+
+// checkFixture type checks the generated code with the fixture source.
+func checkFixture(t *testing.T, filename string, src []byte, out string) {
+	t.Helper()
+	fset := token.NewFileSet()
+	files := make([]*ast.File, 2)
+	var err error
+	if files[0], err = parser.ParseFile(fset, filename, src, 0); err != nil {
+		t.Fatal(err)
+	}
+	if files[1], err = parser.ParseFile(fset, "generated.go", out, 0); err != nil {
+		t.Fatal(err)
+	}
+	config := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+	if _, err := config.Check("fixtures", fset, files, nil); err != nil {
+		t.Fatalf("Generated code does not compile: %s\n%s", err, out)
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"fmt"
 	"go/ast"
 	"go/format"
+	"go/importer"
 	"go/parser"
 	"go/token"
 	"go/types"
@@ -19,6 +20,7 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
@@ -167,7 +169,7 @@ const (
 )
 
 var (
-	njsonPkg                = meta.MustImport(njsonPkgPath)
+	njsonPkg                = mustImport(njsonPkgPath)
 	typNodeJSONUnmarshaler  = njsonPkg.Scope().Lookup("Unmarshaler").Type().Underlying().(*types.Interface)
 	methodNodeUnmarshalJSON = typNodeJSONUnmarshaler.Method(0)
 	typJSONAppender         = njsonPkg.Scope().Lookup("Appender").Type().Underlying().(*types.Interface)
@@ -175,24 +177,50 @@ var (
 	typNode                 = njsonPkg.Scope().Lookup("Node").Type()
 	typNodePtr              = types.NewPointer(typNode)
 
-	unjsonPkg  = meta.MustImport(njsonPkgPath + "/unjson")
+	unjsonPkg  = mustImport(njsonPkgPath + "/unjson")
 	typOmiter  = unjsonPkg.Scope().Lookup("Omiter").Type().Underlying().(*types.Interface)
 	methodOmit = typOmiter.Method(0)
 
-	strjsonPkg = meta.MustImport(njsonPkgPath + "/strjson")
-	numjsonPkg = meta.MustImport(njsonPkgPath + "/numjson")
+	strjsonPkg = mustImport(njsonPkgPath + "/strjson")
+	numjsonPkg = mustImport(njsonPkgPath + "/numjson")
 
-	jsonPkg            = meta.MustImport("encoding/json")
+	jsonPkg            = mustImport("encoding/json")
 	typJSONUnmarshaler = jsonPkg.Scope().Lookup("Unmarshaler").Type().Underlying().(*types.Interface)
 	typJSONMarshaler   = jsonPkg.Scope().Lookup("Marshaler").Type().Underlying().(*types.Interface)
 
-	encodingPkg        = meta.MustImport("encoding")
+	encodingPkg        = mustImport("encoding")
 	typTextUnmarshaler = encodingPkg.Scope().Lookup("TextUnmarshaler").Type().Underlying().(*types.Interface)
 	typTextMarshaler   = encodingPkg.Scope().Lookup("TextMarshaler").Type().Underlying().(*types.Interface)
 
-	strconvPkg = meta.MustImport("strconv")
+	strconvPkg = mustImport("strconv")
+	bytesPkg   = mustImport("bytes")
+	errorsPkg  = mustImport("errors")
 )
 
+// fieldNames returns the field names in sorted order so that generated code is stable.
+func fieldNames(fields meta.Fields) []string {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// srcImporter imports packages from source when there is no export data (ie in module mode)
+var srcImporter = importer.ForCompiler(token.NewFileSet(), "source", nil)
+
+func mustImport(path string) *types.Package {
+	pkg, err := importer.Default().Import(path)
+	if err != nil {
+		pkg, err = srcImporter.Import(path)
+	}
+	if err != nil {
+		panic(err)
+	}
+	return pkg
+}
+
 const (
 	headerComment = `// Code generated by njson on %s; DO NOT EDIT.`
 )
@@ -210,7 +238,13 @@ func (g *Generator) Header() string {
 	h = append(h, fmt.Sprintf(headerComment, ts))
 	h = append(h, fmt.Sprintf("package %s", g.Name()))
 
-	for path, pkg := range g.imports {
+	paths := make([]string, 0, len(g.imports))
+	for path := range g.imports {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		pkg := g.imports[path]
 		if filepath.Base(path) == pkg.Name() {
 			h = append(h, fmt.Sprintf("import %q", path))
 		} else {
@@ -52,6 +52,7 @@ func (o *options) AppendMethod() string {
 }
 
 // TransformFieldCase sets a case transformation mode for field names when no tag based name is found.
+// The modes match unjson.Options.FieldCase so that generated code produces the same JSON.
 func TransformFieldCase(mode string) Option {
 	var fieldNamer func(string) string
 	switch mode {
@@ -104,8 +105,16 @@ func (o *options) TagKey() string {
 
 const (
 	paramOmitempty = "omitempty"
+	paramString    = "string"
+	paramRemain    = "remain"
+	paramInline    = "inline"
 )
 
+// isRemain checks if a field holds the object keys not matching any other field.
+func isRemain(tag meta.Tag) bool {
+	return tag.Params.Has(paramRemain) || tag.Params.Has(paramInline)
+}
+
 func (o *options) parseField(field *types.Var, tag string) (name string, t meta.Tag, ok bool) {
 	if ok = o != nil && field != nil; !ok {
 		return
@@ -132,9 +141,29 @@ func (o *options) parseField(field *types.Var, tag string) (name string, t meta.
 	if o.forceOmitEmpty {
 		t.Params = t.Params.With(paramOmitempty)
 	}
+	if t.Params.Has(paramString) && !canQuote(field.Type()) {
+		// Like encoding/json ignore the string option for non scalar types
+		t.Params.Pop(paramString)
+	}
 	return
 }
 
+// canQuote checks if a type can be encoded as a quoted string with the string tag option.
+func canQuote(t types.Type) bool {
+	if p, ok := t.Underlying().(*types.Pointer); ok {
+		t = p.Elem()
+	}
+	if pt := types.NewPointer(t); types.Implements(pt, typJSONAppender) ||
+		types.Implements(pt, typJSONMarshaler) ||
+		types.Implements(pt, typTextMarshaler) {
+		return false
+	}
+	if b, ok := t.Underlying().(*types.Basic); ok {
+		return b.Info()&(types.IsBoolean|types.IsNumeric) != 0 && b.Info()&types.IsComplex == 0
+	}
+	return false
+}
+
 func (o *options) MatchField(name string) bool {
 	if name == "_" {
 		return false
@@ -0,0 +1,7 @@
+package fixtures
+
+type Profile struct {
+	UserName    string
+	DisplayName string `json:"display,omitempty"`
+	LoginCount  int
+}
@@ -0,0 +1,26 @@
+package fixtures
+
+import "time"
+
+type Base struct {
+	ID      int64     `json:"id,string"`
+	Seq     *uint32   `json:"seq,string"`
+	Created time.Time `json:"created"`
+}
+
+type Order struct {
+	Base
+	Status  string            `json:"status"`
+	Amount  float64           `json:"amount,omitempty"`
+	Paid    bool              `json:"paid,string"`
+	Items   []Item            `json:"items"`
+	Note    *string           `json:"note,omitempty"`
+	Timeout time.Duration     `json:"timeout"`
+	Extra   map[string]string `json:",remain"`
+}
+
+type Item struct {
+	SKU      string `json:"sku"`
+	Quantity uint   `json:"qty"`
+	Raw      []byte `json:",remain"`
+}
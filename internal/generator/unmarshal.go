@@ -150,6 +150,85 @@ default:
 `, t.Elem(), g.TypeUnmarshaler(t.Elem()))
 }
 
+// QuotedUnmarshaler generates the code block to unmarshal a number or boolean encoded as a JSON string.
+// Numbers are parsed from the raw string value so only booleans need special handling.
+func (g *Generator) QuotedUnmarshaler(t types.Type) (code meta.Code) {
+	switch typ := t.Underlying().(type) {
+	case *types.Pointer:
+		return g.Code(`
+switch {
+case n.Type() == njson.TypeNull:
+	*r = nil
+case *r == nil:
+	*r = new(%s)
+	fallthrough
+default:
+	r := *r
+	%s
+}
+`, typ.Elem(), g.QuotedUnmarshaler(typ.Elem()))
+	case *types.Basic:
+		// Integers are quoted to keep their precision so they are parsed with strconv
+		switch typ.Kind() {
+		case types.Int, types.Int8, types.Int16, types.Int32, types.Int64:
+			return g.Code(`
+if n.Type() == njson.TypeString {
+	i, err := strconv.ParseInt(n.Unescaped(), 10, %[2]d)
+	if err != nil {
+		return err
+	}
+	*r = %[1]s(i)
+} else {
+	%[3]s
+}
+`, t, intBits(typ), g.TypeUnmarshaler(t)).Import(njsonPkg, strconvPkg)
+		case types.Uint, types.Uint8, types.Uint16, types.Uint32, types.Uint64, types.Uintptr:
+			return g.Code(`
+if n.Type() == njson.TypeString {
+	u, err := strconv.ParseUint(n.Unescaped(), 10, %[2]d)
+	if err != nil {
+		return err
+	}
+	*r = %[1]s(u)
+} else {
+	%[3]s
+}
+`, t, intBits(typ), g.TypeUnmarshaler(t)).Import(njsonPkg, strconvPkg)
+		case types.Bool:
+			return g.Code(`
+switch raw, typ := n.Data(); {
+case typ&(njson.TypeString|njson.TypeBoolean) == 0:
+	return n.TypeError(njson.TypeString|njson.TypeBoolean)
+case raw == "true":
+	*r = %[1]s(true)
+case raw == "false":
+	*r = %[1]s(false)
+default:
+	return n.TypeError(njson.TypeBoolean)
+}
+`, t).Import(njsonPkg)
+		}
+	}
+	return g.TypeUnmarshaler(t)
+}
+
+// intBits returns the bit size argument of strconv.ParseInt and strconv.ParseUint for an integer type.
+func intBits(b *types.Basic) int {
+	switch b.Kind() {
+	case types.Int8, types.Uint8:
+		return 8
+	case types.Int16, types.Uint16:
+		return 16
+	case types.Int32, types.Uint32:
+		return 32
+	case types.Int64, types.Uint64:
+		return 64
+	default:
+		// Platform sized int, uint and uintptr
+		return 0
+	}
+}
+
 // InterfaceUnmarshaler generates the code block to unmarshal an empty interface.
 func (g *Generator) InterfaceUnmarshaler(t types.Type, b *types.Interface) (code meta.Code) {
 	return code.Import(njsonPkg).Println(`if x, ok := n.ToInterface(); ok { *r = x } else { return n.TypeError(njson.AnyValue) }`)
@@ -226,14 +305,14 @@ func (g *Generator) TimeUnmarshaler(t types.Type) (code meta.Code) {
 func (g *Generator) DurationUnmarshaler(t types.Type) (code meta.Code) {
 	return g.Code(`
 	switch n.Type() {
-	case njson.Number:
-		if f, ok := n.ToNumber(); ok {
-			*r = time.Duration(f)
+	case njson.TypeNumber:
+		if i, ok := n.ToInt(); ok {
+			*r = time.Duration(i)
 		} else {
 			return errors.New("Invalid JSON number")
 		}
-	case njson.String:
-		if t, err := time.ParseDuration(n.Raw()); err != nil {
+	case njson.TypeString:
+		if t, err := time.ParseDuration(n.Unescaped()); err != nil {
 			return err
 		} else {
 			*r = t
@@ -241,7 +320,7 @@ func (g *Generator) DurationUnmarshaler(t types.Type) (code meta.Code) {
 	default:
 		return n.TypeError(njson.TypeNumber|njson.TypeString)
 	}
-	`).Import(types.NewPackage("time", "time"))
+	`).Import(types.NewPackage("time", "time"), errorsPkg)
 }
 
 // TextUnmarshaler generates code to wrap the UnmarshalText method of a value.
@@ -303,7 +382,8 @@ func (g *Generator) StructUnmarshaler(t *types.Struct) (code meta.Code) {
 	fields := meta.NewFields(t, true)
 	tagKey := g.TagKey()
 	used := make(map[string]bool)
-	for name := range fields {
+	var remain *meta.Field
+	for _, name := range fieldNames(fields) {
 		for _, field := range fields[name] {
 			field = field.WithTag(tagKey)
 			if field.Name() == "_" {
@@ -316,6 +396,13 @@ func (g *Generator) StructUnmarshaler(t *types.Struct) (code meta.Code) {
 			if !ok {
 				continue
 			}
+			if isRemain(tag) {
+				if remain == nil || meta.ShortestPath(field.Path, remain.Path) == -1 {
+					f := field
+					remain = &f
+				}
+				continue
+			}
 			if tag.Name != "" {
 				name = tag.Name
 			}
@@ -326,6 +413,8 @@ func (g *Generator) StructUnmarshaler(t *types.Struct) (code meta.Code) {
 			var cf meta.Code
 			if tag.Params.Has("raw") && meta.IsString(field.Type()) {
 				cf = g.RawStringUnmarshaler(field.Type())
+			} else if tag.Params.Has(paramString) {
+				cf = g.QuotedUnmarshaler(field.Type())
 			} else {
 				cf = g.TypeUnmarshaler(field.Type())
 			}
@@ -342,7 +431,8 @@ func (g *Generator) StructUnmarshaler(t *types.Struct) (code meta.Code) {
 			}
 		}
 	}
-	return g.Code(`
+	if remain == nil {
+		return g.Code(`
 		if n.Type() != njson.TypeObject {
 			return n.TypeError(njson.TypeObject)
 		}
@@ -351,7 +441,60 @@ func (g *Generator) StructUnmarshaler(t *types.Struct) (code meta.Code) {
 				%s
 			}
 		}`, code).Import(njsonPkg)
+	}
+	return g.Code(`
+		if n.Type() != njson.TypeObject {
+			return n.TypeError(njson.TypeObject)
+		}
+		%[2]sr%[3]s = nil
+		for values := n.Values(); values.Next(); {
+			switch values.Key() {
+				%[1]s
+			default:
+				n := n.With(values.ID())
+				r := &r%[3]s
+				%[4]s
+			}
+		}`, code, g.EnsurePath(remain.Path), remain.Path, g.RemainUnmarshaler(remain.Type())).Import(njsonPkg)
+
+}
 
+// RemainUnmarshaler generates the code block to store an object key not matching any other field.
+// Map fields decode the value and byte slice fields append it to a raw JSON object.
+func (g *Generator) RemainUnmarshaler(t types.Type) (code meta.Code) {
+	switch typ := t.Underlying().(type) {
+	case *types.Map:
+		return g.Code(`
+if *r == nil {
+	*r = make(%[1]s)
+}
+var v %[2]s
+{
+	r := &v
+	%[3]s
+}
+(*r)[%[4]s(strjson.Unescaped(values.Key()))] = v
+`, t, typ.Elem(), g.TypeUnmarshaler(typ.Elem()), typ.Key()).Import(strjsonPkg)
+	case *types.Slice:
+		if b, ok := typ.Elem().Underlying().(*types.Basic); ok && b.Kind() == types.Byte {
+			return g.Code(`
+if len(*r) == 0 {
+	*r = append(*r, '{')
+} else {
+	(*r)[len(*r)-1] = ','
+}
+*r = append(*r, '"')
+*r = append(*r, values.Key()...)
+*r = append(*r, '"', ':')
+var err error
+if *r, err = n.AppendJSON(*r); err != nil {
+	return err
+}
+*r = append(*r, '}')
+`)
+		}
+	}
+	return code.Errorf("Invalid remain field type %s", t)
 }
 
 // CanUnmarshal returns if can be unmarshaled
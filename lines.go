@@ -0,0 +1,189 @@
+package njson
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"runtime"
+	"strings"
+)
+
+// DefaultLineChunkSize is the default size of input chunks for LineParser
+const DefaultLineChunkSize = 1 << 20
+
+// LineParser parses newline delimited JSON streams (http://ndjson.org/) concurrently.
+//
+// The input is split into chunks of complete lines that are parsed
+// into documents from a Pool by Workers goroutines.
+// Results are delivered in input order on the calling goroutine.
+// At most Workers chunks are parsed ahead of the consumer so
+// memory stays bounded if the consumer is slow.
+type LineParser struct {
+	Workers   int   // Number of parsing goroutines, defaults to runtime.GOMAXPROCS(0)
+	ChunkSize int   // Size of input chunks in bytes, defaults to DefaultLineChunkSize
+	Pool      *Pool // Document pool, defaults to the package pool
+}
+
+// LineError signifies an error parsing a JSON line
+type LineError struct {
+	Line int // Line number starting at 1
+	Err  error
+}
+
+func (e *LineError) Error() string {
+	return fmt.Sprintf("Line %d: %s", e.Line, e.Err)
+}
+
+// Unwrap returns the underlying error
+func (e *LineError) Unwrap() error {
+	return e.Err
+}
+
+var errInvalidLine = errors.New("Invalid line delimited JSON")
+
+// Parse reads newline delimited JSON from r and calls fn for each line in input order.
+//
+// Blank lines are skipped.
+// The Node passed to fn is only valid until fn returns.
+// If fn returns an error parsing stops and Parse returns the error.
+// Parse errors are returned as *LineError.
+func (lp *LineParser) Parse(r io.Reader, fn func(n Node) error) error {
+	workers := lp.Workers
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	size := lp.ChunkSize
+	if size <= 0 {
+		size = DefaultLineChunkSize
+	}
+	pool := lp.Pool
+	if pool == nil {
+		pool = &defaultPool
+	}
+
+	var (
+		jobs    = make(chan *lineChunk)
+		results = make(chan *lineChunk, workers)
+		quit    = make(chan struct{})
+		readErr error
+	)
+	for i := 0; i < workers; i++ {
+		go func() {
+			for c := range jobs {
+				c.parse(pool)
+				close(c.done)
+			}
+		}()
+	}
+	go func() {
+		defer close(results)
+		defer close(jobs)
+		line := 1
+		readErr = readLineChunks(r, size, func(data string) bool {
+			c := lineChunk{
+				data: data,
+				line: line,
+				done: make(chan struct{}),
+			}
+			line += strings.Count(data, "\n")
+			// Queue results first to keep input order
+			select {
+			case results <- &c:
+			case <-quit:
+				return false
+			}
+			select {
+			case jobs <- &c:
+				return true
+			case <-quit:
+				return false
+			}
+		})
+	}()
+
+	for c := range results {
+		<-c.done
+		// Deliver the lines parsed before a parse error first
+		var err error
+		for i := 0; i < len(c.nodes) && err == nil; i++ {
+			err = fn(c.nodes[i])
+		}
+		if err == nil {
+			err = c.err
+		}
+		c.nodes = nil
+		pool.Put(c.doc)
+		if err != nil {
+			close(quit)
+			return err
+		}
+	}
+	return readErr
+}
+
+type lineChunk struct {
+	data  string
+	line  int
+	doc   *Document
+	nodes []Node
+	err   error
+	done  chan struct{}
+}
+
+func (c *lineChunk) parse(pool *Pool) {
+	c.doc = pool.Get()
+	s := c.data
+	for line := c.line; s != ""; line++ {
+		var ln string
+		if i := strings.IndexByte(s, '\n'); i != -1 {
+			ln, s = s[:i], s[i+1:]
+		} else {
+			ln, s = s, ""
+		}
+		if strings.TrimSpace(ln) == "" {
+			continue
+		}
+		n, tail, err := c.doc.Parse(ln)
+		if err == nil && strings.TrimSpace(tail) != "" {
+			err = errInvalidLine
+		}
+		if err != nil {
+			c.err = &LineError{Line: line, Err: err}
+			return
+		}
+		c.nodes = append(c.nodes, n)
+	}
+}
+
+// readLineChunks reads r in chunks of complete lines.
+// Lines longer than size are read into a single larger chunk.
+func readLineChunks(r io.Reader, size int, fn func(data string) bool) error {
+	buf := make([]byte, 0, size)
+	for {
+		n, err := r.Read(buf[len(buf):cap(buf)])
+		buf = buf[:len(buf)+n]
+		if err == io.EOF {
+			if len(buf) > 0 {
+				fn(string(buf))
+			}
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if len(buf) < cap(buf) {
+			continue
+		}
+		end := bytes.LastIndexByte(buf, '\n')
+		if end == -1 {
+			// Line does not fit in buffer
+			buf = append(buf, 0)[:len(buf)]
+			continue
+		}
+		if !fn(string(buf[:end+1])) {
+			return nil
+		}
+		buf = buf[:copy(buf, buf[end+1:])]
+	}
+}
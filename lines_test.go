@@ -0,0 +1,97 @@
+package njson
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestLineParser_Parse(t *testing.T) {
+	var w strings.Builder
+	for i := 0; i < 1000; i++ {
+		fmt.Fprintf(&w, `{"id":%d,"tags":["a","b"]}`, i)
+		if i%7 == 0 {
+			w.WriteString("\n  \r")
+		}
+		w.WriteByte('\n')
+	}
+	// Line longer than chunk size and no trailing newline
+	w.WriteString(`{"id":1000,"pad":"` + strings.Repeat("x", 300) + `"}`)
+	for _, size := range []int{0, 64, 100} {
+		lp := LineParser{Workers: 4, ChunkSize: size}
+		id := 0
+		err := lp.Parse(iotest.HalfReader(strings.NewReader(w.String())), func(n Node) error {
+			got, ok := n.Get("id").ToInt()
+			assert(t, ok, "Invalid id")
+			assertEqual(t, got, int64(id))
+			id++
+			return nil
+		})
+		assertNoError(t, err)
+		assertEqual(t, id, 1001)
+	}
+}
+
+func TestLineParser_ParseError(t *testing.T) {
+	lp := LineParser{Workers: 2, ChunkSize: 16}
+	src := "1\n2\n\n{\"foo\":\"bar\"}\n{\"foo\"}\n5\n"
+	num := 0
+	err := lp.Parse(strings.NewReader(src), func(n Node) error {
+		num++
+		return nil
+	})
+	assertEqual(t, num, 3)
+	var e *LineError
+	assert(t, errors.As(err, &e), "Invalid error %v", err)
+	assertEqual(t, e.Line, 5)
+
+	// Lines before an error in the same chunk are delivered
+	num = 0
+	err = (&LineParser{}).Parse(strings.NewReader("1\n2\n{\"foo\"}\n4\n"), func(n Node) error {
+		num++
+		return nil
+	})
+	assert(t, errors.As(err, &e), "Invalid error %v", err)
+	assertEqual(t, e.Line, 3)
+	assertEqual(t, num, 2)
+
+	num = 0
+	err = lp.Parse(strings.NewReader("1 2\n3\n"), func(n Node) error {
+		num++
+		return nil
+	})
+	assert(t, errors.As(err, &e), "Invalid error %v", err)
+	assertEqual(t, e.Line, 1)
+	assertEqual(t, num, 0)
+
+	stop := errors.New("stop")
+	err = lp.Parse(strings.NewReader(strings.Repeat("[1,2,3]\n", 1000)), func(n Node) error {
+		num++
+		if num == 10 {
+			return stop
+		}
+		return nil
+	})
+	assertEqual(t, err, stop)
+	assertEqual(t, num, 10)
+}
+
+func BenchmarkLineParser(b *testing.B) {
+	var w strings.Builder
+	for w.Len() < 8<<20 {
+		w.WriteString(mediumJSON)
+		w.WriteByte('\n')
+	}
+	src := w.String()
+	lp := LineParser{}
+	b.ReportAllocs()
+	b.SetBytes(int64(len(src)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		lp.Parse(strings.NewReader(src), func(n Node) error {
+			return nil
+		})
+	}
+}
@@ -0,0 +1,378 @@
+package msgpack
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/alxarch/njson"
+	"github.com/alxarch/njson/internal/codec"
+	"github.com/alxarch/njson/numjson"
+	"github.com/alxarch/njson/strjson"
+)
+
+// BytesMode controls how bin family types are converted to strings.
+type BytesMode int
+
+// Bin modes
+const (
+	BytesBase64    BytesMode = iota // base64 with padding
+	BytesBase64URL                  // base64url without padding
+	BytesHex                        // lowercase base16
+)
+
+func (m BytesMode) encode(data []byte) string {
+	switch m {
+	case BytesBase64URL:
+		return base64.RawURLEncoding.EncodeToString(data)
+	case BytesHex:
+		return hex.EncodeToString(data)
+	default:
+		return base64.StdEncoding.EncodeToString(data)
+	}
+}
+
+// Decoder decodes MessagePack data to njson nodes.
+type Decoder struct {
+	Bytes       BytesMode
+	DisallowExt bool // Fail on ext family types other than timestamps
+	MaxDepth    int  // Maximum nesting depth of arrays and maps, DefaultMaxDepth if zero
+}
+
+// DefaultMaxDepth is the maximum nesting depth of values if Decoder.MaxDepth is not set.
+const DefaultMaxDepth = codec.DefaultMaxDepth
+
+// Decode decodes a MessagePack value into a document using the default options.
+// It returns the new node and the remaining data.
+func Decode(d *njson.Document, data []byte) (njson.Node, []byte, error) {
+	dec := Decoder{}
+	return dec.Decode(d, data)
+}
+
+// Decode decodes a MessagePack value into a document.
+// It returns the new node and the remaining data.
+func (dec *Decoder) Decode(d *njson.Document, data []byte) (njson.Node, []byte, error) {
+	p := decodeState{
+		Decoder: dec,
+		State:   codec.State{Format: "MessagePack"},
+		doc:     d,
+		data:    data,
+	}
+	n, err := p.decode()
+	if err != nil {
+		return njson.Node{}, data, err
+	}
+	return n, data[p.Pos:], nil
+}
+
+type decodeState struct {
+	*Decoder
+	codec.State
+	doc  *njson.Document
+	data []byte
+}
+
+// next reads n bytes.
+func (p *decodeState) next(n uint64) ([]byte, error) {
+	if n > uint64(len(p.data)-p.Pos) {
+		return nil, p.EOF()
+	}
+	buf := p.data[p.Pos : p.Pos+int(n)]
+	p.Pos += int(n)
+	return buf, nil
+}
+
+// size reads a big endian length of 1, 2 or 4 bytes.
+func (p *decodeState) size(width int) (uint64, error) {
+	buf, err := p.next(uint64(width))
+	if err != nil {
+		return 0, err
+	}
+	switch width {
+	case 1:
+		return uint64(buf[0]), nil
+	case 2:
+		return uint64(binary.BigEndian.Uint16(buf)), nil
+	default:
+		return uint64(binary.BigEndian.Uint32(buf)), nil
+	}
+}
+
+func (p *decodeState) decode() (njson.Node, error) {
+	d := p.doc
+	if p.Pos >= len(p.data) {
+		return njson.Node{}, p.EOF()
+	}
+	start := p.Pos
+	c := p.data[p.Pos]
+	p.Pos++
+	switch {
+	case c <= 0x7f:
+		return d.NumberRaw(strconv.Itoa(int(c))), nil
+	case c >= codeNegFixIntMin:
+		return d.NumberRaw(strconv.Itoa(int(int8(c)))), nil
+	case c < codeFixArrayMin:
+		return p.object(uint64(c & 0x0f))
+	case c < codeFixStrMin:
+		return p.array(uint64(c & 0x0f))
+	case c < codeNil:
+		return p.text(uint64(c & 0x1f))
+	}
+	switch c {
+	case codeNil:
+		return d.Null(), nil
+	case codeFalse:
+		return d.False(), nil
+	case codeTrue:
+		return d.True(), nil
+	case codeBin8, codeBin16, codeBin32:
+		size, err := p.size(1 << (c - codeBin8))
+		if err != nil {
+			return njson.Node{}, err
+		}
+		data, err := p.next(size)
+		if err != nil {
+			return njson.Node{}, err
+		}
+		return d.TextRaw(p.Bytes.encode(data)), nil
+	case codeExt8, codeExt16, codeExt32:
+		size, err := p.size(1 << (c - codeExt8))
+		if err != nil {
+			return njson.Node{}, err
+		}
+		return p.ext(start, size)
+	case codeFixExt1, codeFixExt2, codeFixExt4, codeFixExt8, codeFixExt16:
+		return p.ext(start, 1<<(c-codeFixExt1))
+	case codeFloat32:
+		buf, err := p.next(4)
+		if err != nil {
+			return njson.Node{}, err
+		}
+		return p.float(float64(math.Float32frombits(binary.BigEndian.Uint32(buf))), 32), nil
+	case codeFloat64:
+		buf, err := p.next(8)
+		if err != nil {
+			return njson.Node{}, err
+		}
+		return p.float(math.Float64frombits(binary.BigEndian.Uint64(buf)), 64), nil
+	case codeUint8, codeUint16, codeUint32, codeUint64:
+		buf, err := p.next(1 << (c - codeUint8))
+		if err != nil {
+			return njson.Node{}, err
+		}
+		return d.NumberRaw(strconv.FormatUint(readUint(buf), 10)), nil
+	case codeInt8, codeInt16, codeInt32, codeInt64:
+		buf, err := p.next(1 << (c - codeInt8))
+		if err != nil {
+			return njson.Node{}, err
+		}
+		return d.NumberRaw(strconv.FormatInt(readInt(buf), 10)), nil
+	case codeStr8, codeStr16, codeStr32:
+		size, err := p.size(1 << (c - codeStr8))
+		if err != nil {
+			return njson.Node{}, err
+		}
+		return p.text(size)
+	case codeArray16, codeArray32:
+		size, err := p.size(2 << (c - codeArray16))
+		if err != nil {
+			return njson.Node{}, err
+		}
+		return p.array(size)
+	case codeMap16, codeMap32:
+		size, err := p.size(2 << (c - codeMap16))
+		if err != nil {
+			return njson.Node{}, err
+		}
+		return p.object(size)
+	default:
+		p.Pos = start
+		return njson.Node{}, p.Errorf("invalid format code")
+	}
+}
+
+func (p *decodeState) text(size uint64) (njson.Node, error) {
+	data, err := p.next(size)
+	if err != nil {
+		return njson.Node{}, err
+	}
+	return p.doc.TextRaw(strjson.Escaped(string(data), false, false)), nil
+}
+
+func (p *decodeState) array(size uint64) (njson.Node, error) {
+	// Each element needs at least one byte
+	if size > uint64(len(p.data)-p.Pos) {
+		return njson.Node{}, p.EOF()
+	}
+	if err := p.Enter(p.MaxDepth); err != nil {
+		return njson.Node{}, err
+	}
+	arr := p.doc.Array()
+	for i := uint64(0); i < size; i++ {
+		el, err := p.decode()
+		if err != nil {
+			return njson.Node{}, err
+		}
+		arr.Append(el)
+	}
+	p.Leave()
+	return arr, nil
+}
+
+func (p *decodeState) object(size uint64) (njson.Node, error) {
+	// Each entry needs at least two bytes
+	if size > uint64(len(p.data)-p.Pos)/2 {
+		return njson.Node{}, p.EOF()
+	}
+	if err := p.Enter(p.MaxDepth); err != nil {
+		return njson.Node{}, err
+	}
+	obj := p.doc.Object()
+	b := codec.NewObjectBuilder(p.doc, obj.ID())
+	for i := uint64(0); i < size; i++ {
+		key, err := p.key()
+		if err != nil {
+			return njson.Node{}, err
+		}
+		v, err := p.decode()
+		if err != nil {
+			return njson.Node{}, err
+		}
+		b.Set(key, v.ID())
+	}
+	p.Leave()
+	return obj, nil
+}
+
+// key decodes a map key to an escaped string.
+func (p *decodeState) key() (string, error) {
+	if p.Pos >= len(p.data) {
+		return "", p.EOF()
+	}
+	start := p.Pos
+	c := p.data[p.Pos]
+	p.Pos++
+	var (
+		size uint64
+		err  error
+	)
+	switch {
+	case c <= 0x7f:
+		return strconv.Itoa(int(c)), nil
+	case c >= codeNegFixIntMin:
+		return strconv.Itoa(int(int8(c))), nil
+	case codeFixStrMin <= c && c < codeNil:
+		size = uint64(c & 0x1f)
+	case c == codeStr8 || c == codeStr16 || c == codeStr32:
+		if size, err = p.size(1 << (c - codeStr8)); err != nil {
+			return "", err
+		}
+	case c == codeBin8 || c == codeBin16 || c == codeBin32:
+		if size, err = p.size(1 << (c - codeBin8)); err != nil {
+			return "", err
+		}
+		data, err := p.next(size)
+		if err != nil {
+			return "", err
+		}
+		return p.Bytes.encode(data), nil
+	case codeUint8 <= c && c <= codeUint64:
+		buf, err := p.next(1 << (c - codeUint8))
+		if err != nil {
+			return "", err
+		}
+		return strconv.FormatUint(readUint(buf), 10), nil
+	case codeInt8 <= c && c <= codeInt64:
+		buf, err := p.next(1 << (c - codeInt8))
+		if err != nil {
+			return "", err
+		}
+		return strconv.FormatInt(readInt(buf), 10), nil
+	default:
+		p.Pos = start
+		return "", p.Errorf("unsupported map key type")
+	}
+	data, err := p.next(size)
+	if err != nil {
+		return "", err
+	}
+	return strjson.Escaped(string(data), false, false), nil
+}
+
+func (p *decodeState) ext(start int, size uint64) (njson.Node, error) {
+	buf, err := p.next(size + 1)
+	if err != nil {
+		return njson.Node{}, err
+	}
+	typ, data := int8(buf[0]), buf[1:]
+	if typ == extTimestamp {
+		tm, ok := readTimestamp(data)
+		if !ok {
+			p.Pos = start
+			return njson.Node{}, p.Errorf("invalid timestamp")
+		}
+		return p.doc.TextRaw(tm.UTC().Format(time.RFC3339Nano)), nil
+	}
+	if p.DisallowExt {
+		p.Pos = start
+		return njson.Node{}, p.Errorf("unsupported ext type " + strconv.Itoa(int(typ)))
+	}
+	obj := p.doc.Object()
+	obj.Set(keyType, p.doc.NumberRaw(strconv.Itoa(int(typ))))
+	obj.Set(keyData, p.doc.TextRaw(base64.StdEncoding.EncodeToString(data)))
+	return obj, nil
+}
+
+func (p *decodeState) float(f float64, bits int) njson.Node {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return p.doc.Null()
+	}
+	return p.doc.NumberRaw(numjson.FormatFloat(f, bits))
+}
+
+// readTimestamp reads the timestamp 32, 64 and 96 formats.
+func readTimestamp(data []byte) (time.Time, bool) {
+	switch len(data) {
+	case 4:
+		return time.Unix(int64(binary.BigEndian.Uint32(data)), 0), true
+	case 8:
+		v := binary.BigEndian.Uint64(data)
+		nsec, sec := int64(v>>34), int64(v&(1<<34-1))
+		return time.Unix(sec, nsec), nsec < 1e9
+	case 12:
+		nsec := int64(binary.BigEndian.Uint32(data))
+		sec := int64(binary.BigEndian.Uint64(data[4:]))
+		return time.Unix(sec, nsec), nsec < 1e9
+	default:
+		return time.Time{}, false
+	}
+}
+
+func readUint(buf []byte) uint64 {
+	switch len(buf) {
+	case 1:
+		return uint64(buf[0])
+	case 2:
+		return uint64(binary.BigEndian.Uint16(buf))
+	case 4:
+		return uint64(binary.BigEndian.Uint32(buf))
+	default:
+		return binary.BigEndian.Uint64(buf)
+	}
+}
+
+func readInt(buf []byte) int64 {
+	switch len(buf) {
+	case 1:
+		return int64(int8(buf[0]))
+	case 2:
+		return int64(int16(binary.BigEndian.Uint16(buf)))
+	case 4:
+		return int64(int32(binary.BigEndian.Uint32(buf)))
+	default:
+		return int64(binary.BigEndian.Uint64(buf))
+	}
+}
@@ -0,0 +1,230 @@
+package msgpack
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/alxarch/njson"
+	"github.com/alxarch/njson/numjson"
+	"github.com/alxarch/njson/strjson"
+)
+
+// Encoder encodes njson nodes to MessagePack.
+type Encoder struct {
+	CompactFloats bool // Use float 32 if it preserves the value
+	UnwrapExt     bool // Encode objects of the form {"type":N,"data":"..."} as ext types
+}
+
+// AppendMsgPack appends the MessagePack encoding of a node to a buffer using the default options.
+func AppendMsgPack(dst []byte, n njson.Node) ([]byte, error) {
+	e := Encoder{}
+	return e.AppendMsgPack(dst, n)
+}
+
+// AppendMsgPack appends the MessagePack encoding of a node to a buffer.
+func (e *Encoder) AppendMsgPack(dst []byte, n njson.Node) ([]byte, error) {
+	raw, typ := n.Data()
+	switch typ {
+	case njson.TypeNull:
+		return append(dst, codeNil), nil
+	case njson.TypeBoolean:
+		if raw == "true" {
+			return append(dst, codeTrue), nil
+		}
+		return append(dst, codeFalse), nil
+	case njson.TypeString:
+		return appendString(dst, strjson.Unescaped(raw)), nil
+	case njson.TypeNumber:
+		return e.appendNumber(dst, raw)
+	case njson.TypeArray:
+		values := n.Values()
+		dst = appendArrayHead(dst, values.Len())
+		var err error
+		for values.Next() {
+			if dst, err = e.AppendMsgPack(dst, values.Value()); err != nil {
+				return dst, err
+			}
+		}
+		return dst, nil
+	case njson.TypeObject:
+		if e.UnwrapExt {
+			if typ, data, ok := unwrapExt(n); ok {
+				return appendExt(dst, typ, data), nil
+			}
+		}
+		values := n.Values()
+		dst = appendMapHead(dst, values.Len())
+		var err error
+		for values.Next() {
+			dst = appendString(dst, strjson.Unescaped(values.Key()))
+			if dst, err = e.AppendMsgPack(dst, values.Value()); err != nil {
+				return dst, err
+			}
+		}
+		return dst, nil
+	default:
+		return dst, n.TypeError(njson.TypeAnyValue)
+	}
+}
+
+// unwrapExt checks if an object node is of the form {"type":N,"data":"..."}.
+func unwrapExt(n njson.Node) (int8, []byte, bool) {
+	if values := n.Values(); values.Len() != 2 {
+		return 0, nil, false
+	}
+	typ, ok := n.Get(keyType).ToInt()
+	if !ok || typ < math.MinInt8 || typ > math.MaxInt8 {
+		return 0, nil, false
+	}
+	raw, t := n.Get(keyData).Data()
+	if t != njson.TypeString {
+		return 0, nil, false
+	}
+	data, err := base64.StdEncoding.DecodeString(strjson.Unescaped(raw))
+	if err != nil {
+		return 0, nil, false
+	}
+	return int8(typ), data, true
+}
+
+func (e *Encoder) appendNumber(dst []byte, raw string) ([]byte, error) {
+	if strings.IndexAny(raw, ".eE") == -1 {
+		if i, err := strconv.ParseInt(raw, 10, 64); err == nil {
+			return appendInt(dst, i), nil
+		}
+		if u, err := strconv.ParseUint(raw, 10, 64); err == nil {
+			return appendUint(dst, u), nil
+		}
+		return dst, fmt.Errorf("Integer %s overflows 64 bits", raw)
+	}
+	f, err := numjson.Parse(raw)
+	if err != nil {
+		return dst, err
+	}
+	if e.CompactFloats {
+		if f32 := float32(f); float64(f32) == f {
+			dst = append(dst, codeFloat32)
+			return appendUint32(dst, math.Float32bits(f32)), nil
+		}
+	}
+	dst = append(dst, codeFloat64)
+	return appendUint64(dst, math.Float64bits(f)), nil
+}
+
+func appendInt(dst []byte, i int64) []byte {
+	switch {
+	case i >= 0:
+		return appendUint(dst, uint64(i))
+	case i >= -32:
+		return append(dst, byte(i))
+	case i >= math.MinInt8:
+		return append(dst, codeInt8, byte(i))
+	case i >= math.MinInt16:
+		return append(dst, codeInt16, byte(i>>8), byte(i))
+	case i >= math.MinInt32:
+		dst = append(dst, codeInt32)
+		return appendUint32(dst, uint32(i))
+	default:
+		dst = append(dst, codeInt64)
+		return appendUint64(dst, uint64(i))
+	}
+}
+
+func appendUint(dst []byte, u uint64) []byte {
+	switch {
+	case u <= 0x7f:
+		return append(dst, byte(u))
+	case u <= math.MaxUint8:
+		return append(dst, codeUint8, byte(u))
+	case u <= math.MaxUint16:
+		return append(dst, codeUint16, byte(u>>8), byte(u))
+	case u <= math.MaxUint32:
+		dst = append(dst, codeUint32)
+		return appendUint32(dst, uint32(u))
+	default:
+		dst = append(dst, codeUint64)
+		return appendUint64(dst, u)
+	}
+}
+
+func appendString(dst []byte, s string) []byte {
+	switch n := len(s); {
+	case n < 32:
+		dst = append(dst, codeFixStrMin|byte(n))
+	case n <= math.MaxUint8:
+		dst = append(dst, codeStr8, byte(n))
+	case n <= math.MaxUint16:
+		dst = append(dst, codeStr16, byte(n>>8), byte(n))
+	default:
+		dst = append(dst, codeStr32)
+		dst = appendUint32(dst, uint32(n))
+	}
+	return append(dst, s...)
+}
+
+func appendArrayHead(dst []byte, n int) []byte {
+	switch {
+	case n < 16:
+		return append(dst, codeFixArrayMin|byte(n))
+	case n <= math.MaxUint16:
+		return append(dst, codeArray16, byte(n>>8), byte(n))
+	default:
+		dst = append(dst, codeArray32)
+		return appendUint32(dst, uint32(n))
+	}
+}
+
+func appendMapHead(dst []byte, n int) []byte {
+	switch {
+	case n < 16:
+		return append(dst, codeFixMapMin|byte(n))
+	case n <= math.MaxUint16:
+		return append(dst, codeMap16, byte(n>>8), byte(n))
+	default:
+		dst = append(dst, codeMap32)
+		return appendUint32(dst, uint32(n))
+	}
+}
+
+func appendExt(dst []byte, typ int8, data []byte) []byte {
+	switch n := len(data); n {
+	case 1:
+		dst = append(dst, codeFixExt1)
+	case 2:
+		dst = append(dst, codeFixExt2)
+	case 4:
+		dst = append(dst, codeFixExt4)
+	case 8:
+		dst = append(dst, codeFixExt8)
+	case 16:
+		dst = append(dst, codeFixExt16)
+	default:
+		switch {
+		case n <= math.MaxUint8:
+			dst = append(dst, codeExt8, byte(n))
+		case n <= math.MaxUint16:
+			dst = append(dst, codeExt16, byte(n>>8), byte(n))
+		default:
+			dst = append(dst, codeExt32)
+			dst = appendUint32(dst, uint32(n))
+		}
+	}
+	dst = append(dst, byte(typ))
+	return append(dst, data...)
+}
+
+func appendUint32(dst []byte, n uint32) []byte {
+	var buf [4]byte
+	binary.BigEndian.PutUint32(buf[:], n)
+	return append(dst, buf[:]...)
+}
+
+func appendUint64(dst []byte, n uint64) []byte {
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], n)
+	return append(dst, buf[:]...)
+}
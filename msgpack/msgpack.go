@@ -0,0 +1,84 @@
+// Package msgpack converts between `njson.Node` values and MessagePack data.
+//
+// ## Encoding
+//
+// JSON values map to MessagePack as follows:
+//   - Numbers without a fraction or exponent are encoded as the smallest int or uint family type.
+//     Integers that do not fit in 64 bits are an error since they cannot be encoded exactly.
+//   - Other numbers are encoded as float 64.
+//     With `Encoder.CompactFloats` float 32 is used if it preserves the value.
+//   - Strings are unescaped and encoded as str family types.
+//   - Arrays and objects are encoded as array and map family types with str keys.
+//   - true, false and null are encoded as bool and nil.
+//
+// With `Encoder.UnwrapExt` objects of the form {"type":N,"data":"..."} are encoded
+// as ext family types, with data in standard base64 encoding.
+//
+// ## Decoding
+//
+// MessagePack data map to JSON values as follows:
+//   - int and uint family types map to numbers keeping their exact value.
+//   - float family types map to numbers. NaN and ±Infinity map to null.
+//   - str family types map to strings.
+//   - bin family types map to strings encoded according to `Decoder.Bytes` (base64 by default).
+//   - The timestamp extension type (-1) maps to an RFC 3339 string in UTC.
+//   - Other ext family types map to objects of the form {"type":N,"data":"..."}
+//     with data in standard base64 encoding, or an error if `Decoder.DisallowExt` is set.
+//   - Maps map to objects. Integer and bin keys are converted to strings.
+//
+// Duplicate map keys keep the last value. Nesting deeper than `Decoder.MaxDepth`
+// (`DefaultMaxDepth` if not set) is an error.
+package msgpack
+
+import "github.com/alxarch/njson/internal/codec"
+
+// Format codes
+const (
+	codeFixMapMin    = 0x80
+	codeFixArrayMin  = 0x90
+	codeFixStrMin    = 0xa0
+	codeNil          = 0xc0
+	codeFalse        = 0xc2
+	codeTrue         = 0xc3
+	codeBin8         = 0xc4
+	codeBin16        = 0xc5
+	codeBin32        = 0xc6
+	codeExt8         = 0xc7
+	codeExt16        = 0xc8
+	codeExt32        = 0xc9
+	codeFloat32      = 0xca
+	codeFloat64      = 0xcb
+	codeUint8        = 0xcc
+	codeUint16       = 0xcd
+	codeUint32       = 0xce
+	codeUint64       = 0xcf
+	codeInt8         = 0xd0
+	codeInt16        = 0xd1
+	codeInt32        = 0xd2
+	codeInt64        = 0xd3
+	codeFixExt1      = 0xd4
+	codeFixExt2      = 0xd5
+	codeFixExt4      = 0xd6
+	codeFixExt8      = 0xd7
+	codeFixExt16     = 0xd8
+	codeStr8         = 0xd9
+	codeStr16        = 0xda
+	codeStr32        = 0xdb
+	codeArray16      = 0xdc
+	codeArray32      = 0xdd
+	codeMap16        = 0xde
+	codeMap32        = 0xdf
+	codeNegFixIntMin = 0xe0
+)
+
+// extTimestamp is the predefined timestamp extension type.
+const extTimestamp = -1
+
+// Keys of objects produced for ext family types
+const (
+	keyType = "type"
+	keyData = "data"
+)
+
+// DecodeError signifies invalid MessagePack data.
+type DecodeError = codec.DecodeError
@@ -0,0 +1,220 @@
+package msgpack
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/alxarch/njson"
+)
+
+func assertNoError(t *testing.T, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+}
+
+func assertEqual(t *testing.T, a, b interface{}) {
+	t.Helper()
+	if !reflect.DeepEqual(a, b) {
+		t.Fatalf("Assertion failed: %v != %v", a, b)
+	}
+}
+
+func mustHex(t *testing.T, s string) []byte {
+	t.Helper()
+	data, err := hex.DecodeString(s)
+	assertNoError(t, err)
+	return data
+}
+
+func TestDecode(t *testing.T) {
+	for _, tc := range []struct {
+		hex  string
+		json string
+	}{
+		{"00", `0`},
+		{"7f", `127`},
+		{"ff", `-1`},
+		{"e0", `-32`},
+		{"cd012c", `300`},
+		{"cfffffffffffffffff", `18446744073709551615`},
+		{"d0df", `-33`},
+		{"d1ff38", `-200`},
+		{"d38000000000000000", `-9223372036854775808`},
+		{"ca3fc00000", `1.5`},
+		{"cb3ff199999999999a", `1.1`},
+		{"cb7ff8000000000000", `null`},
+		{"c0", `null`},
+		{"c2", `false`},
+		{"c3", `true`},
+		{"a3666f6f", `"foo"`},
+		{"d902225c", `"\"\\"`},
+		{"a3e6b0b4", `"水"`},
+		{"c403010203", `"AQID"`},
+		{"93010203", `[1,2,3]`},
+		{"dc0002c0c0", `[null,null]`},
+		{"82a16101a1629301c3c0", `{"a":1,"b":[1,true,null]}`},
+		{"820102c4010103", `{"1":2,"AQ==":3}`},
+		{"d405ff", `{"type":5,"data":"/w=="}`},
+		{"c70301616263", `{"type":1,"data":"YWJj"}`},
+		{"d6ff00000000", `"1970-01-01T00:00:00Z"`},
+		{"d7ff0000000400000001", `"1970-01-01T00:00:01.000000001Z"`},
+	} {
+		d := njson.Document{}
+		n, tail, err := Decode(&d, mustHex(t, tc.hex))
+		assertNoError(t, err)
+		assertEqual(t, len(tail), 0)
+		out, err := n.AppendJSON(nil)
+		assertNoError(t, err)
+		if string(out) != tc.json {
+			t.Errorf("Invalid decode %s: %s != %s", tc.hex, out, tc.json)
+		}
+	}
+}
+
+func TestDecode_Invalid(t *testing.T) {
+	for _, s := range []string{
+		"",
+		"c1",
+		"cd01",
+		"a3666f",
+		"92c0",
+		"81a161",
+		"81c0c0",
+		"dd7fffffff",
+		"d6ff0000",
+		"d7ffffffffff00000000",
+	} {
+		d := njson.Document{}
+		_, _, err := Decode(&d, mustHex(t, s))
+		if _, ok := err.(*DecodeError); !ok {
+			t.Errorf("Invalid error for %q: %v", s, err)
+		}
+	}
+}
+
+func TestDecoder_Options(t *testing.T) {
+	d := njson.Document{}
+	dec := Decoder{Bytes: BytesHex}
+	n, _, err := dec.Decode(&d, mustHex(t, "c403010203"))
+	assertNoError(t, err)
+	assertEqual(t, n.Raw(), "010203")
+
+	dec = Decoder{DisallowExt: true}
+	_, _, err = dec.Decode(&d, mustHex(t, "d405ff"))
+	if _, ok := err.(*DecodeError); !ok {
+		t.Errorf("Invalid error: %v", err)
+	}
+
+	n, tail, err := Decode(&d, mustHex(t, "0102"))
+	assertNoError(t, err)
+	assertEqual(t, n.Raw(), "1")
+	assertEqual(t, tail, []byte{2})
+}
+
+func TestDecode_MaxDepth(t *testing.T) {
+	for _, c := range []byte{0x91, 0x81} {
+		data := bytes.Repeat([]byte{c}, 1<<20)
+		d := njson.Document{}
+		_, _, err := Decode(&d, data)
+		if _, ok := err.(*DecodeError); !ok {
+			t.Errorf("Invalid error for %x: %v", c, err)
+		}
+	}
+	d := njson.Document{}
+	dec := Decoder{MaxDepth: 2}
+	_, _, err := dec.Decode(&d, mustHex(t, "919100"))
+	assertNoError(t, err)
+	_, _, err = dec.Decode(&d, mustHex(t, "91919100"))
+	if _, ok := err.(*DecodeError); !ok {
+		t.Errorf("Invalid error: %v", err)
+	}
+}
+
+func TestDecode_LargeMap(t *testing.T) {
+	const size = 1 << 17
+	data := []byte{0xdf, 0, 0, 0, 0}
+	binary.BigEndian.PutUint32(data[1:], size+1)
+	for i := 0; i < size; i++ {
+		data = append(data, 0xce, 0, 0, 0, 0, 0x00)
+		binary.BigEndian.PutUint32(data[len(data)-5:], uint32(i))
+	}
+	// Duplicate keys replace previous values
+	data = append(data, 0x00, 0x01)
+	d := njson.Document{}
+	n, _, err := Decode(&d, data)
+	assertNoError(t, err)
+	values := n.Values()
+	assertEqual(t, values.Len(), size)
+	assertEqual(t, n.Get("0").Raw(), "1")
+	assertEqual(t, n.Get("1").Raw(), "0")
+}
+
+func TestEncoder(t *testing.T) {
+	for _, tc := range []struct {
+		json    string
+		hex     string
+		compact bool
+	}{
+		{`0`, "00", false},
+		{`-0`, "00", false},
+		{`-1`, "ff", false},
+		{`300`, "cd012c", false},
+		{`-200`, "d1ff38", false},
+		{`18446744073709551615`, "cfffffffffffffffff", false},
+		{`-9223372036854775808`, "d38000000000000000", false},
+		{`1.5`, "cb3ff8000000000000", false},
+		{`1.5`, "ca3fc00000", true},
+		{`1.1`, "cb3ff199999999999a", true},
+		{`"\"\\"`, "a2225c", false},
+		{`[true,false,null]`, "93c3c2c0", false},
+		{`{"a":1,"b":[1,true,null]}`, "82a16101a1629301c3c0", false},
+	} {
+		d := njson.Document{}
+		n, _, err := d.Parse(tc.json)
+		assertNoError(t, err)
+		e := Encoder{CompactFloats: tc.compact}
+		out, err := e.AppendMsgPack(nil, n)
+		assertNoError(t, err)
+		if h := hex.EncodeToString(out); h != tc.hex {
+			t.Errorf("Invalid encoding %s: %s != %s", tc.json, h, tc.hex)
+		}
+	}
+}
+
+func TestEncoder_Errors(t *testing.T) {
+	d := njson.Document{}
+	n, _, err := d.Parse(`18446744073709551616`)
+	assertNoError(t, err)
+	if _, err := AppendMsgPack(nil, n); err == nil {
+		t.Errorf("Expected overflow error")
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	src := `{"id":42,"name":"cache \"entry\"","score":-12.5,"ok":true,"data":null,"tags":["a","b"],"long":"` + strings.Repeat("x", 300) + `"}`
+	d := njson.Document{}
+	n, _, err := d.Parse(src)
+	assertNoError(t, err)
+	data, err := AppendMsgPack(nil, n)
+	assertNoError(t, err)
+	v, tail, err := Decode(&d, data)
+	assertNoError(t, err)
+	assertEqual(t, len(tail), 0)
+	out, err := v.AppendJSON(nil)
+	assertNoError(t, err)
+	assertEqual(t, string(out), src)
+
+	// Ext types survive a round trip with UnwrapExt
+	v, _, err = Decode(&d, mustHex(t, "c70301616263"))
+	assertNoError(t, err)
+	e := Encoder{UnwrapExt: true}
+	data, err = e.AppendMsgPack(nil, v)
+	assertNoError(t, err)
+	assertEqual(t, hex.EncodeToString(data), "c70301616263")
+}
@@ -8,6 +8,7 @@ import (
 	"strconv"
 	"sync"
 
+	"github.com/alxarch/njson/internal/codec"
 	"github.com/alxarch/njson/numjson"
 	"github.com/alxarch/njson/strjson"
 )
@@ -48,26 +49,27 @@ func (n Node) Document() *Document {
 
 func (n Node) get() *node {
 	if n.doc != nil && n.doc.rev == n.rev {
-		if n.id < uint(len(n.doc.nodes)) {
-			return &n.doc.nodes[n.id]
-		}
-	} else {
-		// Unlink invalid Document reference
-		n.doc = nil
+		return n.doc.get(n.id)
 	}
+	// Unlink invalid Document reference
+	n.doc = nil
 	return nil
 }
 
 // AppendJSON appends a node's JSON data to a byte slice.
 func (n Node) AppendJSON(dst []byte) ([]byte, error) {
 	if nn := n.get(); nn != nil {
+		if len(n.doc.spans) != 0 {
+			return n.doc.appendSource(dst, n.id)
+		}
 		return n.doc.appendJSON(dst, nn)
 	}
-	return nil, &typeError{TypeInvalid, TypeAnyValue}
+	return nil, newTypeError(TypeInvalid, TypeAnyValue)
 }
 
 // Raw returns the JSON string of a Node's value.
 // Object and Array nodes return an empty string.
+// Use Source() to get the input text of nodes parsed with Document.ParseSpans.
 func (n Node) Raw() string {
 	if n := n.get(); n != nil {
 		return n.raw
@@ -149,17 +151,23 @@ func (n Node) Values() IterV {
 
 // TypeError returns an error for a type not matching a Node's type.
 func (n Node) TypeError(want Type) error {
-	return typeError{n.Type(), want}
+	return newTypeError(n.Type(), want)
 }
 
 // Lookup finds a node by path
+// Object keys with escapes in the JSON input match their unescaped form.
 func (n Node) Lookup(path ...string) Node {
 	return n.With(n.Document().lookup(n.id, path))
 }
 
 // ToInterface converts a Node to a generic interface{}.
 func (n Node) ToInterface() (interface{}, bool) {
-	return n.Document().toInterface(n.id)
+	return n.Document().toInterface(n.id, &InterfaceOptions{})
+}
+
+// ToInterfaceWith converts a Node to a generic interface{} using options.
+func (n Node) ToInterfaceWith(opts InterfaceOptions) (interface{}, bool) {
+	return n.Document().toInterface(n.id, &opts)
 }
 
 var bufferpool = &sync.Pool{
@@ -192,7 +200,7 @@ func (n Node) PrintJSON(w io.Writer) (int, error) {
 func (n Node) WrapUnmarshalJSON(u json.Unmarshaler) (err error) {
 	node := n.get()
 	if node == nil {
-		return typeError{TypeInvalid, TypeAnyValue}
+		return newTypeError(TypeInvalid, TypeAnyValue)
 	}
 
 	switch node.info.Type() {
@@ -209,7 +217,7 @@ func (n Node) WrapUnmarshalJSON(u json.Unmarshaler) (err error) {
 			return u.UnmarshalJSON([]byte{delimString, delimString})
 		}
 	case TypeInvalid:
-		return typeError{TypeInvalid, TypeAnyValue}
+		return newTypeError(TypeInvalid, TypeAnyValue)
 	}
 	data := bufferpool.Get().([]byte)
 	data, err = n.AppendJSON(data[:0])
@@ -238,15 +246,14 @@ func (n Node) WrapUnmarshalText(u encoding.TextUnmarshaler) (err error) {
 }
 
 // Get gets a Node by key.
+// Keys with escapes in the JSON input match their unescaped form.
 // If the key is not found the returned node's id
 // will be MaxID and the Node will behave as empty.
 func (n Node) Get(key string) Node {
 	if nn := n.get(); nn != nil && nn.info.IsObject() {
-		for i := range nn.values {
-			if key == nn.values[i].key {
-				n.id = nn.values[i].id
-				return n
-			}
+		if i := findKey(nn.values, key); 0 <= i && i < len(nn.values) {
+			n.id = nn.values[i].id
+			return n
 		}
 	}
 	n.id = maxUint
@@ -268,7 +275,7 @@ func (n Node) Index(i int) Node {
 
 // Set assigns a Node to the key of an Object Node.
 // Since most keys need no escaping it doesn't escape the key.
-// If the key needs escaping use strjson.Escaped.
+// If the key needs escaping use SetKeyEscaped.
 func (n Node) Set(key string, value Node) {
 	if nn := n.get(); nn != nil && nn.info.IsObject() {
 		// Make a copy of the value if it's not Orphan to avoid recursion infinite loops.
@@ -292,6 +299,46 @@ func (n Node) Set(key string, value Node) {
 	}
 }
 
+// add appends a key to an Object Node without checking for an existing key.
+func (n Node) add(key string, value Node) {
+	if nn := n.get(); nn != nil && nn.info.IsObject() {
+		id := n.doc.copyOrAdopt(value.Document(), value.ID(), n.id)
+		if id < maxUint {
+			// copyOrAdopt might grow nodes array invalidating nn pointer
+			nn = &n.doc.nodes[n.id]
+			nn.values = append(nn.values, V{
+				id:  id,
+				key: key,
+			})
+		}
+	}
+}
+
+func init() {
+	// Binary decoders build objects through codec.ObjectBuilder
+	codec.SetValue = func(doc interface{}, id uint, key string, value uint) {
+		d := doc.(*Document)
+		d.Node(id).Set(key, d.Node(value))
+	}
+	codec.AddValue = func(doc interface{}, id uint, key string, value uint) {
+		d := doc.(*Document)
+		d.Node(id).add(key, d.Node(value))
+	}
+}
+
+// SetKeyEscaped assigns a Node to the key of an Object Node escaping the key.
+// An existing key is replaced if it matches the key in unescaped form.
+func (n Node) SetKeyEscaped(key string, value Node) {
+	if nn := n.get(); nn != nil && nn.info.IsObject() {
+		if i := findKey(nn.values, key); 0 <= i && i < len(nn.values) {
+			key = nn.values[i].key
+		} else {
+			key = strjson.Escaped(key, false, false)
+		}
+		n.Set(key, value)
+	}
+}
+
 // Append appends a node id to an Array node's values.
 func (n Node) Append(values ...Node) {
 	if len(values) == 0 {
@@ -345,44 +392,44 @@ func (n Node) Remove(i int) {
 }
 
 // Strip recursively deletes a key from a node.
+// Keys are matched like Del.
 func (n Node) Strip(key string) {
 	if nn := n.get(); nn != nil && nn.info.IsObject() {
+		if i := findKey(nn.values, key); 0 <= i && i < len(nn.values) {
+			n.doc.delValue(nn, i)
+		}
 		for i := range nn.values {
-			v := &nn.values[i]
-			if key == v.key {
-				if j := len(nn.values) - 1; 0 <= j && j < len(nn.values) {
-					nn.values[i] = nn.values[j]
-					nn.values[j] = V{}
-					nn.values = nn.values[:j]
-					for j := i; 0 <= j && j < len(nn.values); j++ {
-						n.With(nn.values[j].id).Strip(key)
-					}
-				}
-				return
-			}
-			n.With(v.id).Strip(key)
+			n.With(nn.values[i].id).Strip(key)
 		}
 	}
-
 }
 
 // Del finds a key in an Object node's values and removes it.
-// It does not keep the order of keys.
+// It does not keep the order of keys unless the document was parsed with ParseSpans.
 func (n Node) Del(key string) {
-	if n := n.get(); n != nil && n.info.IsObject() {
-		for i := range n.values {
-			if n.values[i].key == key {
-				if j := len(n.values) - 1; 0 <= j && j < len(n.values) {
-					n.values[i] = n.values[j]
-					n.values[j] = V{}
-					n.values = n.values[:j]
-				}
-				return
-			}
+	if nn := n.get(); nn != nil && nn.info.IsObject() {
+		if i := findKey(nn.values, key); 0 <= i && i < len(nn.values) {
+			n.doc.delValue(nn, i)
 		}
 	}
 }
 
+// delValue removes the value at offset i of a node.
+// Documents with spans keep the order of values so that AppendJSON follows the source.
+func (d *Document) delValue(n *node, i int) {
+	j := len(n.values) - 1
+	if j < 0 || i < 0 || i > j {
+		return
+	}
+	if len(d.spans) != 0 {
+		copy(n.values[i:], n.values[i+1:])
+	} else {
+		n.values[i] = n.values[j]
+	}
+	n.values[j] = V{}
+	n.values = n.values[:j]
+}
+
 // SetInt sets a Node's value to an integer.
 func (n Node) SetInt(i int64) {
 	if n := n.get(); n != nil {
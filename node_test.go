@@ -3,6 +3,7 @@ package njson
 import (
 	"bytes"
 	"encoding/json"
+	"math/big"
 	"reflect"
 	"testing"
 )
@@ -268,7 +269,7 @@ func TestNode_WrapUnmarshalJSON(t *testing.T) {
 			t.Errorf("Expected error got nil")
 		} else if c.Foo != 0 {
 			t.Errorf("Unexpected value: %d", c.Foo)
-		} else if e, ok := err.(typeError); !ok {
+		} else if e, ok := err.(*TypeError); !ok {
 			t.Errorf("Unexpected error: %v", err)
 		} else if e.Want != TypeAnyValue {
 			t.Errorf("Unexpected type error: %v", e.Want)
@@ -308,7 +309,7 @@ func TestNode_WrapUnmarshalText(t *testing.T) {
 			t.Errorf("Unexpected error: %s", err)
 		}
 		err = n.WrapUnmarshalText(&c)
-		assertEqual(t, err, typeError{TypeObject, TypeString})
+		assertEqual(t, err, &TypeError{Type: TypeObject, Want: TypeString})
 	}
 }
 func TestNode_Unescaped(t *testing.T) {
@@ -544,6 +545,64 @@ func TestNode_Lookup(t *testing.T) {
 	}
 }
 
+func TestNode_GetEscaped(t *testing.T) {
+	d := Document{}
+	n, _, err := d.Parse(`{"caf\u00e9":1,"a\"b":{"c\/d":2},"plain":3}`)
+	assertNoError(t, err)
+	assertEqual(t, n.Get("café").Raw(), "1")
+	assertEqual(t, n.Get(`caf\u00e9`).Raw(), "1")
+	assertEqual(t, n.Lookup(`a"b`, "c/d").Raw(), "2")
+	assertEqual(t, n.Get("plain").Raw(), "3")
+	assertEqual(t, n.Get("cafe").Type(), TypeInvalid)
+	n.Del("café")
+	assertEqual(t, n.Get("café").Type(), TypeInvalid)
+}
+
+func TestNode_StripEscaped(t *testing.T) {
+	d := Document{}
+	n, _, err := d.Parse(`{"a\u0062":1,"c":{"\u0061b":2,"d":3}}`)
+	assertNoError(t, err)
+	n.Strip("ab")
+	data, err := n.AppendJSON(nil)
+	assertNoError(t, err)
+	assertEqual(t, string(data), `{"c":{"d":3}}`)
+}
+
+func TestUnescapedEqual(t *testing.T) {
+	for _, tc := range []struct {
+		raw, key string
+		want     bool
+	}{
+		{`a\u0062`, "ab", true},
+		{`a\u0062`, "a", false},
+		{`a\u0062c`, "abc", true},
+		{`a\u0062c`, "abd", false},
+		{`\"\n\/`, "\"\n/", true},
+		{`caf\u00e9`, "café", true},
+		{`\ud83d\ude00!`, "😀!", true},
+		{`\ud83d\ude00`, "😀!", false},
+	} {
+		assertEqual(t, unescapedEqual(tc.raw, tc.key), tc.want)
+	}
+	values := []V{{key: "foo"}, {key: `caf\u00e9`}}
+	allocs := testing.AllocsPerRun(100, func() {
+		findKey(values, "café")
+	})
+	assertEqual(t, allocs, 0.0)
+}
+
+func TestNode_SetKeyEscaped(t *testing.T) {
+	d := Document{}
+	n, _, err := d.Parse(`{"caf\u00e9":1}`)
+	assertNoError(t, err)
+	n.SetKeyEscaped("café", d.Text("foo"))
+	n.SetKeyEscaped("a\"b\n", d.True())
+	data, err := n.AppendJSON(nil)
+	assertNoError(t, err)
+	assertEqual(t, string(data), `{"caf\u00e9":"foo","a\"b\n":true}`)
+	assertEqual(t, n.Get("a\"b\n").Raw(), "true")
+}
+
 func TestNode_Append(t *testing.T) {
 	d := Document{}
 	n := d.Array()
@@ -647,6 +706,10 @@ func TestNode_SetX(t *testing.T) {
 	n.Set("foo", d.Text("bar"))
 	n.Set("foo", d.Text("baz"))
 	assertEqual(t, n.Get("foo").Raw(), "baz")
+	n.add("bar", d.Text("foo"))
+	data, err := n.AppendJSON(nil)
+	assertNoError(t, err)
+	assertEqual(t, string(data), `{"foo":"baz","bar":"foo"}`)
 
 }
 
@@ -680,3 +743,38 @@ func TestNode_Empty(t *testing.T) {
 		assertEqual(t, n.ID(), uint(maxUint))
 	}
 }
+
+func TestNode_ToInterfaceWith(t *testing.T) {
+	d := Document{}
+	n, _, err := d.Parse(`{"b":1,"a":[1.0,1.5,-9223372036854775809,1e2,"x\n"],"c":null}`)
+	assertNoError(t, err)
+
+	x, ok := n.ToInterfaceWith(InterfaceOptions{UseNumber: true})
+	assert(t, ok, "Conversion failed")
+	assertEqual(t, x, map[string]interface{}{
+		"a": []interface{}{json.Number("1.0"), json.Number("1.5"), json.Number("-9223372036854775809"), json.Number("1e2"), "x\n"},
+		"b": json.Number("1"),
+		"c": nil,
+	})
+
+	big, _ := new(big.Int).SetString("-9223372036854775809", 10)
+	x, ok = n.ToInterfaceWith(InterfaceOptions{Integers: true, OrderedObjects: true})
+	assert(t, ok, "Conversion failed")
+	assertEqual(t, x, Members{
+		{"b", int64(1)},
+		{"a", []interface{}{int64(1), 1.5, big, int64(100), "x\n"}},
+		{"c", nil},
+	})
+	v, ok := x.(Members).Get("b")
+	assertEqual(t, v, int64(1))
+	assertEqual(t, ok, true)
+	_, ok = x.(Members).Get("d")
+	assertEqual(t, ok, false)
+
+	n, _, err = d.Parse(`[1.2.3]`)
+	assertNoError(t, err)
+	_, ok = n.ToInterfaceWith(InterfaceOptions{Integers: true})
+	assertEqual(t, ok, false)
+	x, ok = n.ToInterfaceWith(InterfaceOptions{UseNumber: true})
+	assertEqual(t, ok, true)
+}
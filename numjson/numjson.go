@@ -1,12 +1,17 @@
 package numjson
 
 import (
+	"errors"
+	"fmt"
 	"math"
 	"strconv"
 )
 
 var fNaN = math.NaN()
 
+// ErrInvalidNumber is the error for values that are not valid JSON numbers.
+var ErrInvalidNumber = errors.New("Invalid number")
+
 // ParseFloat parses a float number from a string.
 func ParseFloat(s string) float64 {
 	var (
@@ -129,6 +134,63 @@ fallback:
 
 }
 
+// Parse parses a JSON number.
+// It returns an error wrapping ErrInvalidNumber if s is not a valid JSON number
+// or if its value overflows float64.
+func Parse(s string) (float64, error) {
+	if !Valid(s) {
+		return fNaN, fmt.Errorf("%w %q", ErrInvalidNumber, s)
+	}
+	f := ParseFloat(s)
+	if math.IsInf(f, 0) {
+		return f, fmt.Errorf("%w %q: value out of range", ErrInvalidNumber, s)
+	}
+	return f, nil
+}
+
+// Valid checks if s is a valid JSON number.
+func Valid(s string) bool {
+	i := 0
+	if i < len(s) && s[i] == '-' {
+		i++
+	}
+	switch {
+	case i == len(s):
+		return false
+	case s[i] == '0':
+		i++
+	case '1' <= s[i] && s[i] <= '9':
+		i = skipDigits(s, i+1)
+	default:
+		return false
+	}
+	if i < len(s) && s[i] == '.' {
+		j := skipDigits(s, i+1)
+		if j == i+1 {
+			return false
+		}
+		i = j
+	}
+	if i < len(s) && (s[i] == 'e' || s[i] == 'E') {
+		if i++; i < len(s) && (s[i] == '+' || s[i] == '-') {
+			i++
+		}
+		j := skipDigits(s, i)
+		if j == i {
+			return false
+		}
+		i = j
+	}
+	return i == len(s)
+}
+
+func skipDigits(s string, i int) int {
+	for i < len(s) && '0' <= s[i] && s[i] <= '9' {
+		i++
+	}
+	return i
+}
+
 // ParseInt parses an int from string
 func ParseInt(s string) (int64, bool) {
 	f := ParseFloat(s)
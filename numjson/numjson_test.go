@@ -1,6 +1,7 @@
 package numjson
 
 import (
+	"errors"
 	"math"
 	"strconv"
 	"testing"
@@ -157,3 +158,20 @@ func TestParseInt(t *testing.T) {
 	}
 
 }
+
+func TestParse(t *testing.T) {
+	for _, s := range []string{"0", "-0", "1.5", "-12e3", "1E+2", "0.1e-2"} {
+		f, err := Parse(s)
+		if err != nil {
+			t.Errorf("Parse(%q) unexpected error %s", s, err)
+		}
+		if want, _ := strconv.ParseFloat(s, 64); f != want {
+			t.Errorf("Parse(%q) = %v != %v", s, f, want)
+		}
+	}
+	for _, s := range []string{"", "-", "01", "1.", ".1", "1e", "1e+", "+1", "0x10", "Inf", "NaN", "1_0", "1e400"} {
+		if _, err := Parse(s); !errors.Is(err, ErrInvalidNumber) {
+			t.Errorf("Parse(%q) invalid error %v", s, err)
+		}
+	}
+}
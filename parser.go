@@ -8,6 +8,9 @@ type parser struct {
 	nodes []node
 	n     uint
 	err   error
+	lazy  bool // skip nested objects and arrays
+	spans []span
+	src   string // input text if recording spans
 }
 
 // Parse parses a JSON string and returns the root node
@@ -93,9 +96,23 @@ func (p *parser) parseValue(s string, pos uint) uint {
 				goto readString
 			}
 			if c == delimBeginObject {
+				if p.lazy {
+					return p.skip(s, pos, vObject)
+				}
+				if p.src != "" {
+					id := p.n
+					return p.span(id, pos, p.parseObject(s, pos+1))
+				}
 				return p.parseObject(s, pos+1)
 			}
 			if c == delimBeginArray {
+				if p.lazy {
+					return p.skip(s, pos, vArray)
+				}
+				if p.src != "" {
+					id := p.n
+					return p.span(id, pos, p.parseArray(s, pos+1))
+				}
 				return p.parseArray(s, pos+1)
 			}
 			if bytemapIsDigit[c] == 1 {
@@ -206,6 +223,12 @@ done:
 		n.values[i] = V{}
 	}
 	n.values = n.values[:0]
+	if p.src != "" {
+		if info == vString {
+			return p.span(p.n-1, pos-uint(len(s))-2, pos)
+		}
+		return p.span(p.n-1, pos-uint(len(s)), pos)
+	}
 	return pos
 }
 
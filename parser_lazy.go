@@ -0,0 +1,223 @@
+package njson
+
+import (
+	"strings"
+)
+
+// ParseLazy parses a JSON string and returns the root node.
+//
+// Nested objects and arrays are only scanned to find where they end.
+// Their values are parsed the first time the node is accessed by Get, Index,
+// Values, Lookup or any other method that needs them.
+// Each expansion parses one level, so untouched nested values are never parsed.
+//
+// Only string and bracket boundaries of objects and arrays are checked by
+// ParseLazy. If a node's values turn out to be invalid when it's expanded,
+// its type is set to TypeInvalid. Path getters like GetString and AppendJSON
+// return the parse error of such nodes.
+//
+// ParseLazy reserves a node for every nested value so that expanding a node
+// fills reserved nodes in place and never moves existing nodes.
+// Since expanding modifies the document, nodes of a lazy document are not
+// safe for concurrent reads.
+func (d *Document) ParseLazy(s string) (Node, string, error) {
+	p := d.parser()
+	p.lazy = true
+	id := p.n
+	// Values of the root node are always parsed
+	pos := uint(0)
+	for pos < uint(len(s)) && bytemapIsSpace[s[pos]] == 1 {
+		pos++
+	}
+	switch {
+	case pos < uint(len(s)) && s[pos] == delimBeginObject:
+		pos = p.parseObject(s, pos+1)
+	case pos < uint(len(s)) && s[pos] == delimBeginArray:
+		pos = p.parseArray(s, pos+1)
+	default:
+		pos = p.parseValue(s, pos)
+	}
+	switch p.err.(type) {
+	case nil:
+		d.nodes = p.nodes[:p.n]
+		d.nodes[id].info |= infRoot
+		// Return tail of input string
+		if pos < uint(len(s)) {
+			return Node{id, d.rev, d}, s[pos:], nil
+		}
+		return Node{id, d.rev, d}, "", nil
+	case UnexpectedEOF:
+		// Return input as is. Caller can append more data and re-parse.
+		return Node{}, s, p.err
+	default:
+		return Node{}, "", p.err
+	}
+}
+
+// skip adds a lazy node for the object or array starting at pos.
+func (p *parser) skip(s string, pos uint, inf info) uint {
+	start := pos
+	pos, size := p.skipValues(s, pos, inf.Type())
+	if p.err != nil {
+		return pos
+	}
+	n := p.node()
+	n.set(inf|infLazy, s[start:pos])
+	for i := range n.values {
+		n.values[i] = V{}
+	}
+	n.values = n.values[:0]
+	p.reserve(size)
+	return pos
+}
+
+// reserve skips size nodes after the last node.
+// The values of a lazy node are parsed into the nodes reserved after it.
+// Reserved nodes are not referenced by any node until then so they are not cleared.
+func (p *parser) reserve(size uint) {
+	if end := p.n + size; end > uint(len(p.nodes)) {
+		nodes := make([]node, 2*len(p.nodes)+int(size))
+		copy(nodes, p.nodes[:p.n])
+		p.nodes = nodes
+	}
+	p.n += size
+}
+
+// skipValues is like skipContainer but also counts the values nested at any depth
+// in the object or array starting at pos.
+func (p *parser) skipValues(s string, pos uint, typ Type) (uint, uint) {
+	var depth, size uint
+	for ; pos < uint(len(s)); pos++ {
+		c := s[pos]
+		if bytemapIsSkipToken[c] == 0 {
+			if c == delimValueSeparator {
+				size++
+			}
+			continue
+		}
+		switch c {
+		case delimString:
+			if pos = p.skipString(s, pos) - 1; p.err != nil {
+				return pos, 0
+			}
+		case delimBeginObject, delimBeginArray:
+			depth++
+			// Non empty containers have one more value than separators
+			for i := pos + 1; i < uint(len(s)); i++ {
+				if c := s[i]; bytemapIsSpace[c] == 0 {
+					if c != delimEndObject && c != delimEndArray {
+						size++
+					}
+					break
+				}
+			}
+		default:
+			if depth--; depth == 0 {
+				return pos + 1, size
+			}
+		}
+	}
+	return p.eof(typ, pos), 0
+}
+
+// skipContainer scans the input only to find the closing bracket of the
+// object or array starting at pos and returns the position after it.
+func (p *parser) skipContainer(s string, pos uint, typ Type) uint {
+	var depth uint
+	for ; pos < uint(len(s)); pos++ {
+		if bytemapIsSkipToken[s[pos]] == 0 {
+			continue
+		}
+		switch s[pos] {
+		case delimString:
+			if pos = p.skipString(s, pos) - 1; p.err != nil {
+				return pos
+			}
+		case delimBeginObject, delimBeginArray:
+			depth++
+		default:
+			if depth--; depth == 0 {
+				return pos + 1
+			}
+		}
+	}
+	return p.eof(typ, pos)
+}
+
+// skipString finds the closing quote of the string starting at pos and returns the position after it.
+func (p *parser) skipString(s string, pos uint) uint {
+	for {
+		i := strings.IndexByte(s[pos+1:], delimString)
+		if i == -1 {
+			return p.eof(TypeString, uint(len(s)))
+		}
+		pos += uint(i) + 1
+		// The quote is escaped if it follows an odd number of backslashes
+		j := pos - 1
+		for s[j] == delimEscape {
+			j--
+		}
+		if (pos-j)%2 == 1 {
+			return pos + 1
+		}
+	}
+}
+
+// expand parses the values of a lazy node into the nodes reserved after it and returns the node.
+func (d *Document) expand(id uint) *node {
+	var (
+		n   = &d.nodes[id]
+		raw = n.raw
+		inf = n.info
+		pos uint
+	)
+	// Clear the flag first so the node is never expanded twice
+	n.info &^= infLazy
+	// Limit capacity so that the parser cannot grow d.nodes
+	p := parser{
+		nodes: d.nodes[:len(d.nodes):len(d.nodes)],
+		n:     id,
+		lazy:  true,
+	}
+	switch inf.Type() {
+	case TypeObject:
+		pos = p.parseObject(raw, 1)
+	case TypeArray:
+		pos = p.parseArray(raw, 1)
+	}
+	n = &d.nodes[id]
+	flags := inf.Flags() &^ infLazy
+	if p.err != nil || pos != uint(len(raw)) || len(p.nodes) != len(d.nodes) {
+		for i := range n.values {
+			n.values[i] = V{}
+		}
+		*n = node{
+			info:   flags | infInvalid,
+			raw:    raw,
+			values: n.values[:0],
+		}
+		return n
+	}
+	n.info |= flags
+	return n
+}
+
+// err returns the parse error of a lazy node with invalid values or nil.
+func (n *node) err() error {
+	if n == nil || n.info&infInvalid == 0 {
+		return nil
+	}
+	// Parse the values again to find the error
+	p := parser{lazy: true}
+	typ := TypeArray
+	if strings.HasPrefix(n.raw, "{") {
+		typ = TypeObject
+		p.parseObject(n.raw, 1)
+	} else {
+		p.parseArray(n.raw, 1)
+	}
+	if p.err != nil {
+		return p.err
+	}
+	return newTypeError(TypeInvalid, typ)
+}
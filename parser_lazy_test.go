@@ -0,0 +1,153 @@
+package njson
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestDocument_ParseLazy(t *testing.T) {
+	for _, src := range []string{
+		largeJSON,
+		mediumJSON,
+		mediumJSONFormatted,
+		smallJSON,
+		twitterJSON,
+		canadaJSON,
+		`42`,
+		`"foo"`,
+		`{}`,
+		`[]`,
+		`{"a\"b":"c\\n","d":[1,2,[3,{}],[]],"e":{"f":{"g":"}]"}}}`,
+		"[ 1 , [ \"bar\" ] ,\r\n{\"baz\":true} ]",
+	} {
+		expect := Document{}
+		want, wantTail, err := expect.Parse(src)
+		assertNoError(t, err)
+		d := Document{}
+		n, tail, err := d.ParseLazy(src)
+		assertNoError(t, err)
+		assertEqual(t, tail, wantTail)
+		got, err := n.AppendJSON(nil)
+		assertNoError(t, err)
+		out, _ := want.AppendJSON(nil)
+		assertEqual(t, string(got), string(out))
+	}
+}
+
+func TestDocument_ParseLazyExpand(t *testing.T) {
+	d := Document{}
+	n, tail, err := d.ParseLazy(`{"a":{"b":[1,2,{"c":true}]},"d":[{"e":null}]} {}`)
+	assertNoError(t, err)
+	assertEqual(t, tail, " {}")
+	assertEqual(t, n.Type(), TypeObject)
+	// A node is reserved for each nested value
+	assertEqual(t, len(d.nodes), 10)
+	assertEqual(t, d.nodes[1].info, vObject|infLazy)
+	assertEqual(t, d.nodes[7].info, vArray|infLazy)
+	// Expanding fills reserved nodes in place
+	root := d.get(0)
+	assertEqual(t, n.Lookup("a", "b", "2", "c").Raw(), "true")
+	assertEqual(t, len(d.nodes), 10)
+	assert(t, root == &d.nodes[0], "Expanding moved nodes")
+	// Node "d" is not expanded
+	assertEqual(t, d.nodes[7].info, vArray|infLazy)
+	assertEqual(t, n.Get("d").Index(0).Get("e").Type(), TypeNull)
+	iter := n.Get("a").Get("b").Values()
+	assertEqual(t, iter.Len(), 3)
+	assertEqual(t, len(d.nodes), 10)
+}
+
+func TestDocument_ParseLazyHeldNode(t *testing.T) {
+	src := `{"a":[{"b":[1,{"c":"d"}]},[[],{}]],"e":{"f":[true,null]}}`
+	d := Document{}
+	_, _, err := d.ParseLazy(src)
+	assertNoError(t, err)
+	// appendJSON holds the parent node pointer while its children expand
+	held := d.get(0)
+	out, err := d.appendJSON(nil, held)
+	assertNoError(t, err)
+	assertEqual(t, string(out), src)
+	assert(t, held == &d.nodes[0], "Expanding moved nodes")
+	assertEqual(t, held.info, vObject|infRoot)
+	assertEqual(t, len(held.values), 2)
+	x, ok := d.Root().ToInterface()
+	assert(t, ok, "Invalid interface")
+	assertEqual(t, x, map[string]interface{}{
+		"a": []interface{}{
+			map[string]interface{}{"b": []interface{}{1.0, map[string]interface{}{"c": "d"}}},
+			[]interface{}{[]interface{}{}, map[string]interface{}{}},
+		},
+		"e": map[string]interface{}{"f": []interface{}{true, nil}},
+	})
+}
+
+func TestDocument_ParseLazyInvalid(t *testing.T) {
+	for _, src := range []string{
+		``,
+		`{`,
+		`[1,2`,
+		`{"foo":"bar}`,
+		`{"foo":"bar\"}`,
+		`[[]`,
+	} {
+		d := Document{}
+		_, tail, err := d.ParseLazy(src)
+		if err == nil {
+			t.Errorf("Expected error for %q", src)
+			continue
+		}
+		if _, ok := err.(UnexpectedEOF); !ok {
+			t.Errorf("Invalid error for %q: %s", src, err)
+		}
+		assertEqual(t, tail, src)
+	}
+	// Invalid values are only detected when expanded
+	d := Document{}
+	n, _, err := d.ParseLazy(`{"a":[1,,2],"b":{"c":1]}`)
+	assertNoError(t, err)
+	assertEqual(t, n.Get("a").Type(), TypeInvalid)
+	assertEqual(t, n.Get("b").Type(), TypeInvalid)
+	_, err = n.AppendJSON(nil)
+	if _, ok := err.(*ParseError); !ok {
+		t.Errorf("Invalid error for invalid lazy nodes: %v", err)
+	}
+	// Lookups that expand invalid values return the parse error
+	n, _, err = d.ParseLazy(`{"a":{"b":1,}}`)
+	assertNoError(t, err)
+	_, err = n.GetInt("a", "b")
+	var pathErr *PathError
+	assert(t, errors.As(err, &pathErr), "Invalid error %v", err)
+	assertEqual(t, pathErr.Path, []string{"a"})
+	var parseErr *ParseError
+	assert(t, errors.As(err, &parseErr), "Invalid error %v", err)
+	_, err = n.GetInt("a")
+	assert(t, errors.As(err, &parseErr), "Invalid error %v", err)
+}
+
+func BenchmarkParseLazy(b *testing.B) {
+	for _, tc := range []struct {
+		name string
+		src  string
+	}{
+		{"small.json", smallJSON},
+		{"medium.json", mediumJSONFormatted},
+		{"large.json", largeJSON},
+		{"twitter.json", twitterJSON},
+		{"canada.json", canadaJSON},
+	} {
+		src := tc.src
+		b.Run(tc.name, func(b *testing.B) {
+			d := Document{}
+			b.ReportAllocs()
+			b.SetBytes(int64(len(src)))
+			for i := 0; i < b.N; i++ {
+				d.Reset()
+				n, _, err := d.ParseLazy(src)
+				if err != nil {
+					b.Fatal(err)
+				}
+				n.Type()
+			}
+		})
+	}
+}
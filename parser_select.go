@@ -0,0 +1,325 @@
+package njson
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/alxarch/njson/strjson"
+)
+
+// ParseSelect parses a JSON string building nodes only for the selected paths.
+//
+// Paths use the same keys as Lookup, object keys or array indexes.
+// The root node contains only the branches leading to selected paths and
+// the full subtree of each selected path is parsed.
+// All other values are skipped scanning the input only to find where they end.
+// Values that do not match the structure of a path are omitted.
+// If no paths are provided an empty root object or array is returned and
+// an empty path selects the whole document.
+func (d *Document) ParseSelect(s string, paths ...[]string) (Node, string, error) {
+	sel := selector{index: -1}
+	for _, path := range paths {
+		sel.add(path)
+	}
+	p := d.parser()
+	id := p.n
+	pos := uint(0)
+	for pos < uint(len(s)) && bytemapIsSpace[s[pos]] == 1 {
+		pos++
+	}
+	switch {
+	case sel.all:
+		pos = p.parseValue(s, pos)
+	case pos < uint(len(s)) && s[pos] == delimBeginObject:
+		pos = p.parseSelectObject(s, pos+1, &sel)
+	case pos < uint(len(s)) && s[pos] == delimBeginArray:
+		pos = p.parseSelectArray(s, pos+1, &sel)
+	default:
+		// Scalar root values are returned as is
+		pos = p.parseValue(s, pos)
+	}
+	switch p.err.(type) {
+	case nil:
+		d.nodes = p.nodes[:p.n]
+		d.get(id).info |= infRoot
+		// Return tail of input string
+		if pos < uint(len(s)) {
+			return Node{id, d.rev, d}, s[pos:], nil
+		}
+		return Node{id, d.rev, d}, "", nil
+	case UnexpectedEOF:
+		// Return input as is. Caller can append more data and re-parse.
+		return Node{}, s, p.err
+	default:
+		return Node{}, "", p.err
+	}
+}
+
+// selector is a tree of selected paths.
+type selector struct {
+	key      string
+	index    int  // array index of key or -1
+	all      bool // selects the whole value
+	children []selector
+}
+
+func (sel *selector) add(path []string) {
+	if sel.all {
+		return
+	}
+	if len(path) == 0 {
+		sel.all = true
+		sel.children = nil
+		return
+	}
+	key := path[0]
+	for i := range sel.children {
+		if c := &sel.children[i]; c.key == key {
+			c.add(path[1:])
+			return
+		}
+	}
+	c := selector{key: key, index: -1}
+	if i, err := strconv.Atoi(key); err == nil && i >= 0 {
+		c.index = i
+	}
+	c.add(path[1:])
+	sel.children = append(sel.children, c)
+}
+
+// get returns the selector for a raw object key.
+func (sel *selector) get(key string) *selector {
+	for i := range sel.children {
+		if c := &sel.children[i]; c.key == key {
+			return c
+		}
+	}
+	if strings.IndexByte(key, delimEscape) != -1 {
+		key = strjson.Unescaped(key)
+		for i := range sel.children {
+			if c := &sel.children[i]; c.key == key {
+				return c
+			}
+		}
+	}
+	return nil
+}
+
+// at returns the selector for an array index.
+func (sel *selector) at(index int) *selector {
+	for i := range sel.children {
+		if c := &sel.children[i]; c.index == index {
+			return c
+		}
+	}
+	return nil
+}
+
+// parseSelect parses the value at pos if it matches the selector.
+// It returns false if the value was skipped.
+func (p *parser) parseSelect(s string, pos uint, sel *selector) (uint, bool) {
+	if sel.all {
+		return p.parseValue(s, pos), true
+	}
+	for ; pos < uint(len(s)); pos++ {
+		switch s[pos] {
+		case delimBeginObject:
+			return p.parseSelectObject(s, pos+1, sel), true
+		case delimBeginArray:
+			return p.parseSelectArray(s, pos+1, sel), true
+		}
+		if bytemapIsSpace[s[pos]] == 0 {
+			break
+		}
+	}
+	return p.skipValue(s, pos), false
+}
+
+// skipValue scans the value at pos without adding any nodes.
+func (p *parser) skipValue(s string, pos uint) uint {
+	for ; pos < uint(len(s)); pos++ {
+		c := s[pos]
+		switch {
+		case bytemapIsSpace[c] == 1:
+			continue
+		case c == delimString:
+			return p.skipString(s, pos)
+		case c == delimBeginObject:
+			return p.skipContainer(s, pos, TypeObject)
+		case c == delimBeginArray:
+			return p.skipContainer(s, pos, TypeArray)
+		case c == '-' || c == 't' || c == 'f' || c == 'n' || bytemapIsDigit[c] == 1:
+			for pos++; pos < uint(len(s)); pos++ {
+				if bytemapIsNumberEnd[s[pos]] == 1 {
+					break
+				}
+			}
+			return pos
+		default:
+			return p.abort(pos, TypeAnyValue, c, "any value")
+		}
+	}
+	return p.eof(TypeAnyValue, pos)
+}
+
+// selectValues sets the values of a parsed object or array node.
+func (p *parser) selectValues(id uint, values []V, numV uint) {
+	if id < uint(len(p.nodes)) {
+		n := &p.nodes[id]
+		// Zero out unused values to release key strings
+		for i := numV; i < uint(len(n.values)); i++ {
+			n.values[i] = V{}
+		}
+		n.values = values[:numV]
+	}
+}
+
+func (p *parser) parseSelectObject(s string, pos uint, sel *selector) uint {
+	var (
+		id     = p.n
+		n      = p.node()
+		c      byte
+		key    string
+		values = n.values[:cap(n.values)]
+		numV   uint
+		i      uint
+		ok     bool
+	)
+	n.set(vObject, "")
+	// Skip space after opening '{'
+	for ; pos < uint(len(s)); pos++ {
+		c = s[pos]
+		switch c {
+		case delimEndObject:
+			p.selectValues(id, values, 0)
+			return pos + 1
+		case delimString:
+			goto readKey
+		default:
+			if bytemapIsSpace[c] == 0 {
+				return p.abort(pos, TypeObject, c, []rune{delimEndObject, delimString})
+			}
+		}
+	}
+	return p.eof(TypeObject, pos)
+
+readKey:
+	// Current pos is at the opening quote of a key.
+	for i = pos + 1; i < uint(len(s)); i++ {
+		switch s[i] {
+		case delimString:
+			key = s[pos+1 : i]
+			// Skip space after closing quote
+			for pos = i + 1; pos < uint(len(s)); pos++ {
+				c = s[pos]
+				if c == delimNameSeparator {
+					goto readValue
+				}
+				if bytemapIsSpace[c] == 0 {
+					return p.abort(pos, TypeObject, c, delimNameSeparator)
+				}
+			}
+			return p.eof(TypeObject, pos)
+		case delimEscape:
+			i++
+		}
+	}
+	return p.eof(TypeObject, i)
+
+readValue:
+	// We're at ':' after key
+	if child := sel.get(key); child != nil {
+		vid := p.n
+		if pos, ok = p.parseSelect(s, pos+1, child); ok {
+			values = appendV(values, key, vid, numV)
+			numV++
+		}
+	} else {
+		pos = p.skipValue(s, pos+1)
+	}
+	if p.err != nil {
+		return pos
+	}
+	// Skip space after value
+	for ; pos < uint(len(s)); pos++ {
+		c = s[pos]
+		switch c {
+		case delimValueSeparator:
+			// Skip space after ','
+			for pos++; pos < uint(len(s)); pos++ {
+				c = s[pos]
+				if c == delimString {
+					goto readKey
+				}
+				if bytemapIsSpace[c] == 0 {
+					return p.abort(pos, TypeObject, c, delimString)
+				}
+			}
+			return p.eof(TypeObject, pos)
+		case delimEndObject:
+			p.selectValues(id, values, numV)
+			return pos + 1
+		default:
+			if bytemapIsSpace[c] == 0 {
+				return p.abort(pos, TypeObject, c, []rune{delimValueSeparator, delimEndObject})
+			}
+		}
+	}
+	return p.eof(TypeObject, pos)
+}
+
+func (p *parser) parseSelectArray(s string, pos uint, sel *selector) uint {
+	var (
+		id     = p.n
+		n      = p.node()
+		c      byte
+		values = n.values[:cap(n.values)]
+		numV   uint
+		index  int
+		ok     bool
+	)
+	n.set(vArray, "")
+	// Skip space after '['
+	for ; pos < uint(len(s)); pos++ {
+		c = s[pos]
+		if bytemapIsSpace[c] == 0 {
+			if c == delimEndArray {
+				p.selectValues(id, values, 0)
+				return pos + 1
+			}
+			goto readValue
+		}
+	}
+	return p.eof(TypeArray, pos)
+readValue:
+	if child := sel.at(index); child != nil {
+		vid := p.n
+		if pos, ok = p.parseSelect(s, pos, child); ok {
+			values = appendV(values, "", vid, numV)
+			numV++
+		}
+	} else {
+		pos = p.skipValue(s, pos)
+	}
+	if p.err != nil {
+		return pos
+	}
+	index++
+	// Skip space after value
+	for ; pos < uint(len(s)); pos++ {
+		c = s[pos]
+		switch c {
+		case delimValueSeparator:
+			pos++
+			goto readValue
+		case delimEndArray:
+			p.selectValues(id, values, numV)
+			return pos + 1
+		default:
+			if bytemapIsSpace[c] == 0 {
+				return p.abort(pos, TypeArray, c, []rune{delimValueSeparator, delimEndArray})
+			}
+		}
+	}
+	return p.eof(TypeArray, pos)
+}
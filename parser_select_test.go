@@ -0,0 +1,96 @@
+package njson
+
+import "testing"
+
+func TestDocument_ParseSelect(t *testing.T) {
+	const src = `{
+		"id": 42,
+		"user": {"name": "foo", "tags": ["a", "b"], "meta": {"x": [1, {"y": 2}]}},
+		"items": [{"id": 1, "skip": "]}"}, {"id": 2}, {"id": 3, "sub": {"id": 4}}],
+		"esc\"key": "\"",
+		"scalar": true
+	}`
+	for _, tc := range []struct {
+		paths [][]string
+		want  string
+	}{
+		{nil, `{}`},
+		{[][]string{{"id"}}, `{"id":42}`},
+		{[][]string{{"id"}, {"user", "name"}}, `{"id":42,"user":{"name":"foo"}}`},
+		{[][]string{{"user", "meta"}, {"user", "meta", "x"}}, `{"user":{"meta":{"x":[1,{"y":2}]}}}`},
+		{[][]string{{"user", "tags", "1"}}, `{"user":{"tags":["b"]}}`},
+		{[][]string{{"items", "2", "sub", "id"}, {"items", "0", "id"}}, `{"items":[{"id":1},{"sub":{"id":4}}]}`},
+		{[][]string{{"scalar", "foo"}, {"missing"}}, `{}`},
+		{[][]string{{`esc\"key`}}, `{"esc\"key":"\""}`},
+		{[][]string{{`esc"key`}}, `{"esc\"key":"\""}`},
+		{[][]string{{}}, `{"id":42,"user":{"name":"foo","tags":["a","b"],"meta":{"x":[1,{"y":2}]}},"items":[{"id":1,"skip":"]}"},{"id":2},{"id":3,"sub":{"id":4}}],"esc\"key":"\"","scalar":true}`},
+	} {
+		d := Document{}
+		n, tail, err := d.ParseSelect(src, tc.paths...)
+		assertNoError(t, err)
+		assertEqual(t, tail, "")
+		out, err := n.AppendJSON(nil)
+		assertNoError(t, err)
+		assertEqual(t, string(out), tc.want)
+	}
+}
+
+func TestDocument_ParseSelectTestdata(t *testing.T) {
+	d := Document{}
+	n, _, err := d.ParseSelect(twitterJSON, []string{"statuses", "1", "user", "screen_name"}, []string{"search_metadata", "count"})
+	assertNoError(t, err)
+	full := Document{}
+	root, _, err := full.Parse(twitterJSON)
+	assertNoError(t, err)
+	assertEqual(t, n.Lookup("statuses", "0", "user", "screen_name").Raw(), root.Lookup("statuses", "1", "user", "screen_name").Raw())
+	assertEqual(t, n.Lookup("search_metadata", "count").Raw(), root.Lookup("search_metadata", "count").Raw())
+	if len(d.nodes) != 7 {
+		t.Errorf("Invalid number of nodes: %d", len(d.nodes))
+	}
+}
+
+func TestDocument_ParseSelectScalar(t *testing.T) {
+	d := Document{}
+	n, tail, err := d.ParseSelect(` "foo" 42`, []string{"foo"})
+	assertNoError(t, err)
+	assertEqual(t, tail, " 42")
+	assertEqual(t, n.Raw(), "foo")
+}
+
+func TestDocument_ParseSelectInvalid(t *testing.T) {
+	for _, src := range []string{
+		``,
+		`{"a":`,
+		`{"a":{"b":1}`,
+		`{"b":"foo`,
+		`["foo`,
+		`{"a":1 "b":2}`,
+		`{"b":}`,
+		`[1,,2]`,
+	} {
+		d := Document{}
+		n, _, err := d.ParseSelect(src, []string{"a"}, []string{"0"})
+		if err == nil {
+			t.Errorf("Expected error for %q", src)
+		}
+		assertEqual(t, n, Node{})
+	}
+}
+
+func BenchmarkParseSelect(b *testing.B) {
+	paths := [][]string{
+		{"statuses", "0", "id"},
+		{"statuses", "0", "user", "screen_name"},
+		{"search_metadata", "count"},
+	}
+	src := twitterJSON
+	d := Document{}
+	b.ReportAllocs()
+	b.SetBytes(int64(len(src)))
+	for i := 0; i < b.N; i++ {
+		d.Reset()
+		if _, _, err := d.ParseSelect(src, paths...); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package njson
+
+// Span is a range of byte offsets in the input a node was parsed from.
+type Span struct {
+	Start int
+	End   int
+}
+
+type span struct {
+	src   string
+	start uint
+	end   uint
+}
+
+// ParseSpans parses a JSON string like Parse recording the input span of each node.
+//
+// Use Node.Span() and Node.Source() to access the original text of any node
+// including objects and arrays.
+// Spans are relative to the input of each ParseSpans call.
+//
+// AppendJSON output of nodes parsed with ParseSpans preserves the input formatting.
+// Whitespace and the original spelling of numbers and strings are reproduced
+// byte-for-byte for all values that were not modified after parsing.
+func (d *Document) ParseSpans(s string) (Node, string, error) {
+	p := d.parser()
+	p.src = s
+	p.spans = d.spans
+	id := p.n
+	pos := p.parseValue(s, 0)
+	switch p.err.(type) {
+	case nil:
+		d.nodes = p.nodes[:p.n]
+		d.spans = p.spans
+		d.get(id).info |= infRoot
+		// Return tail of input string
+		if pos < uint(len(s)) {
+			return Node{id, d.rev, d}, s[pos:], nil
+		}
+		return Node{id, d.rev, d}, "", nil
+	case UnexpectedEOF:
+		// Return input as is. Caller can append more data and re-parse.
+		return Node{}, s, p.err
+	default:
+		return Node{}, "", p.err
+	}
+}
+
+// span records the input span of a node and returns end.
+func (p *parser) span(id, start, end uint) uint {
+	for uint(len(p.spans)) <= id {
+		p.spans = append(p.spans, span{})
+	}
+	p.spans[id] = span{p.src, start, end}
+	return end
+}
+
+// span returns the recorded input span of a node.
+func (d *Document) span(id uint) *span {
+	if id < uint(len(d.spans)) {
+		if sp := &d.spans[id]; sp.src != "" {
+			return sp
+		}
+	}
+	return nil
+}
+
+// Span returns the byte offsets of a node's value in the parsed input.
+// It returns false if the node was not parsed with Document.ParseSpans.
+func (n Node) Span() (Span, bool) {
+	if d := n.Document(); d != nil {
+		if sp := d.span(n.id); sp != nil {
+			return Span{int(sp.start), int(sp.end)}, true
+		}
+	}
+	return Span{}, false
+}
+
+// Source returns the exact input text of a node's value including objects and arrays.
+// It returns an empty string if the node was not parsed with Document.ParseSpans.
+// Source does not reflect modifications of the node after parsing.
+func (n Node) Source() string {
+	if d := n.Document(); d != nil {
+		if sp := d.span(n.id); sp != nil {
+			return sp.src[sp.start:sp.end]
+		}
+	}
+	return ""
+}
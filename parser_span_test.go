@@ -0,0 +1,80 @@
+package njson
+
+import "testing"
+
+func TestDocument_ParseSpans(t *testing.T) {
+	src := "{\n  \"a\" : [ 1, \"two\" ,{ }],\n  \"b\": {\"c\":null, \"d\" :\"\"}\n} "
+	d := Document{}
+	n, tail, err := d.ParseSpans(src)
+	assertNoError(t, err)
+	assertEqual(t, tail, " ")
+	assertEqual(t, n.Source(), src[:len(src)-1])
+	for _, tc := range []struct {
+		Path   []string
+		Source string
+	}{
+		{[]string{"a"}, `[ 1, "two" ,{ }]`},
+		{[]string{"a", "0"}, `1`},
+		{[]string{"a", "1"}, `"two"`},
+		{[]string{"a", "2"}, `{ }`},
+		{[]string{"b"}, `{"c":null, "d" :""}`},
+		{[]string{"b", "c"}, `null`},
+		{[]string{"b", "d"}, `""`},
+	} {
+		v := n.Lookup(tc.Path...)
+		span, ok := v.Span()
+		assert(t, ok, "No span for %v", tc.Path)
+		assertEqual(t, src[span.Start:span.End], tc.Source)
+		assertEqual(t, v.Source(), tc.Source)
+	}
+
+	// Nodes not parsed with ParseSpans
+	v := d.Text("foo")
+	n.Set("e", v)
+	_, ok := v.Span()
+	assert(t, !ok, "Unexpected span")
+	assertEqual(t, v.Source(), "")
+
+	// Spans are relative to each input
+	m, _, err := d.ParseSpans(`[true, {"x":1}]`)
+	assertNoError(t, err)
+	assertEqual(t, m.Index(1).Source(), `{"x":1}`)
+	assertEqual(t, n.Get("a").Source(), `[ 1, "two" ,{ }]`)
+
+	m, _, err = d.Parse(`{"y":2}`)
+	assertNoError(t, err)
+	assertEqual(t, m.Source(), "")
+	assertEqual(t, m.Get("y").Source(), "")
+
+	d.Reset()
+	m, _, err = d.Parse(`[1]`)
+	assertNoError(t, err)
+	assertEqual(t, m.Source(), "")
+}
+
+func TestDocument_ParseSpansTestdata(t *testing.T) {
+	for _, src := range []string{
+		mediumJSON,
+		mediumJSONFormatted,
+		twitterJSON,
+	} {
+		d := Document{}
+		n, _, err := d.ParseSpans(src)
+		assertNoError(t, err)
+		assertEqual(t, n.Source(), src[:len(n.Source())])
+		plain := Document{}
+		_, _, err = plain.Parse(src)
+		assertNoError(t, err)
+		// Every node's source must parse to the same JSON
+		for id := range d.nodes {
+			want, err := plain.Node(uint(id)).AppendJSON(nil)
+			assertNoError(t, err)
+			tmp := Document{}
+			w, _, err := tmp.Parse(d.Node(uint(id)).Source())
+			assertNoError(t, err)
+			got, err := w.AppendJSON(nil)
+			assertNoError(t, err)
+			assertEqual(t, string(got), string(want))
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package njson
+
+import (
+	"time"
+)
+
+// lookupType finds a node by path and checks its type.
+// Errors name the path up to the failing segment.
+func (n Node) lookupType(want Type, path []string) (Node, error) {
+	var (
+		d  = n.Document()
+		id = n.id
+	)
+	for i := range path {
+		typ := TypeInvalid
+		if p := d.get(id); p != nil {
+			if err := p.err(); err != nil {
+				return Node{}, &PathError{path[:i], err}
+			}
+			typ = p.info.Type()
+		}
+		if typ != TypeObject && typ != TypeArray {
+			return Node{}, &TypeError{typ, TypeObject | TypeArray, path[:i]}
+		}
+		if id = d.lookup(id, path[i:i+1]); id == maxUint {
+			return Node{}, &PathError{path[:i+1], ErrMissingValue}
+		}
+	}
+	v := n.With(id)
+	if err := v.get().err(); err != nil {
+		return Node{}, &PathError{path, err}
+	}
+	switch typ := v.Type(); {
+	case typ == TypeInvalid:
+		return Node{}, &PathError{path, ErrMissingValue}
+	case typ&want == 0:
+		return Node{}, &TypeError{typ, want, path}
+	}
+	return v, nil
+}
+
+// GetString finds a String node by path and returns its unescaped value.
+func (n Node) GetString(path ...string) (string, error) {
+	v, err := n.lookupType(TypeString, path)
+	if err != nil {
+		return "", err
+	}
+	return v.Unescaped(), nil
+}
+
+// GetInt finds a Number node by path and converts it to int64.
+func (n Node) GetInt(path ...string) (int64, error) {
+	v, err := n.lookupType(TypeNumber, path)
+	if err != nil {
+		return 0, err
+	}
+	if i, ok := v.ToInt(); ok {
+		return i, nil
+	}
+	return 0, &TypeError{TypeNumber, TypeNumber, path}
+}
+
+// GetUint finds a Number node by path and converts it to uint64.
+func (n Node) GetUint(path ...string) (uint64, error) {
+	v, err := n.lookupType(TypeNumber, path)
+	if err != nil {
+		return 0, err
+	}
+	if u, ok := v.ToUint(); ok {
+		return u, nil
+	}
+	return 0, &TypeError{TypeNumber, TypeNumber, path}
+}
+
+// GetFloat finds a Number node by path and converts it to float64.
+func (n Node) GetFloat(path ...string) (float64, error) {
+	v, err := n.lookupType(TypeNumber, path)
+	if err != nil {
+		return 0, err
+	}
+	if f, ok := v.ToFloat(); ok {
+		return f, nil
+	}
+	return 0, &TypeError{TypeNumber, TypeNumber, path}
+}
+
+// GetBool finds a Boolean node by path and converts it to bool.
+func (n Node) GetBool(path ...string) (bool, error) {
+	v, err := n.lookupType(TypeBoolean, path)
+	if err != nil {
+		return false, err
+	}
+	b, _ := v.ToBool()
+	return b, nil
+}
+
+// GetTime finds a String node by path and parses it as time using layout.
+func (n Node) GetTime(layout string, path ...string) (time.Time, error) {
+	s, err := n.GetString(path...)
+	if err != nil {
+		return time.Time{}, err
+	}
+	t, err := time.Parse(layout, s)
+	if err != nil {
+		return time.Time{}, &PathError{path, err}
+	}
+	return t, nil
+}
+
+// GetStringDefault returns the value of GetString or def if it fails.
+func (n Node) GetStringDefault(def string, path ...string) string {
+	if s, err := n.GetString(path...); err == nil {
+		return s
+	}
+	return def
+}
+
+// GetIntDefault returns the value of GetInt or def if it fails.
+func (n Node) GetIntDefault(def int64, path ...string) int64 {
+	if i, err := n.GetInt(path...); err == nil {
+		return i
+	}
+	return def
+}
+
+// GetUintDefault returns the value of GetUint or def if it fails.
+func (n Node) GetUintDefault(def uint64, path ...string) uint64 {
+	if u, err := n.GetUint(path...); err == nil {
+		return u
+	}
+	return def
+}
+
+// GetFloatDefault returns the value of GetFloat or def if it fails.
+func (n Node) GetFloatDefault(def float64, path ...string) float64 {
+	if f, err := n.GetFloat(path...); err == nil {
+		return f
+	}
+	return def
+}
+
+// GetBoolDefault returns the value of GetBool or def if it fails.
+func (n Node) GetBoolDefault(def bool, path ...string) bool {
+	if b, err := n.GetBool(path...); err == nil {
+		return b
+	}
+	return def
+}
+
+// GetTimeDefault returns the value of GetTime or def if it fails.
+func (n Node) GetTimeDefault(def time.Time, layout string, path ...string) time.Time {
+	if t, err := n.GetTime(layout, path...); err == nil {
+		return t
+	}
+	return def
+}
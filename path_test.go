@@ -0,0 +1,75 @@
+package njson
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNode_GetX(t *testing.T) {
+	d := Document{}
+	n, _, err := d.Parse(`{
+		"user": {"name": "foo\nbar", "age": 42, "score": 4.5, "admin": true, "created": "2018-10-25T23:25:22Z"},
+		"items": [{"id": 1}, {"id": -2}],
+		"null": null
+	}`)
+	assertNoError(t, err)
+
+	s, err := n.GetString("user", "name")
+	assertNoError(t, err)
+	assertEqual(t, s, "foo\nbar")
+	i, err := n.GetInt("items", "1", "id")
+	assertNoError(t, err)
+	assertEqual(t, i, int64(-2))
+	u, err := n.GetUint("user", "age")
+	assertNoError(t, err)
+	assertEqual(t, u, uint64(42))
+	f, err := n.GetFloat("user", "score")
+	assertNoError(t, err)
+	assertEqual(t, f, 4.5)
+	b, err := n.GetBool("user", "admin")
+	assertNoError(t, err)
+	assertEqual(t, b, true)
+	tm, err := n.GetTime(time.RFC3339, "user", "created")
+	assertNoError(t, err)
+	assertEqual(t, tm.Equal(time.Date(2018, 10, 25, 23, 25, 22, 0, time.UTC)), true)
+	i, err = n.Get("user").GetInt("age")
+	assertNoError(t, err)
+	assertEqual(t, i, int64(42))
+
+	for _, tc := range []struct {
+		err  error
+		want string
+	}{
+		{second(n.GetString("user", "age")), `Invalid type Number not in [String] at "user.age"`},
+		{second(n.GetString("user", "missing")), `Missing value at "user.missing"`},
+		{second(n.GetString("user", "name", "first")), `Invalid type String not in [Object Array] at "user.name"`},
+		{second(n.GetInt("items", "5", "id")), `Missing value at "items.5"`},
+		{second(n.GetInt("user", "score")), `Invalid value for type Number at "user.score"`},
+		{second(n.GetUint("items", "1", "id")), `Invalid value for type Number at "items.1.id"`},
+		{second(n.GetBool("null")), `Invalid type Null not in [Boolean] at "null"`},
+		{second(n.GetFloat("null", "foo")), `Invalid type Null not in [Object Array] at "null"`},
+	} {
+		assert(t, tc.err != nil, "Expected error %q", tc.want)
+		assertEqual(t, tc.err.Error(), tc.want)
+	}
+	_, err = n.GetTime(time.RFC3339, "user", "name")
+	var perr *time.ParseError
+	assert(t, errors.As(err, &perr), "Invalid error %v", err)
+	assert(t, strings.HasSuffix(err.Error(), ` at "user.name"`), "Invalid error %v", err)
+	_, err = n.GetString("user", "missing")
+	assert(t, errors.Is(err, ErrMissingValue), "Invalid error %v", err)
+
+	assertEqual(t, n.GetStringDefault("bar", "user", "missing"), "bar")
+	assertEqual(t, n.GetStringDefault("bar", "user", "name"), "foo\nbar")
+	assertEqual(t, n.GetIntDefault(7, "user", "name"), int64(7))
+	assertEqual(t, n.GetUintDefault(7, "user", "age"), uint64(42))
+	assertEqual(t, n.GetFloatDefault(1.5, "null"), 1.5)
+	assertEqual(t, n.GetBoolDefault(true, "user", "admin"), true)
+	assertEqual(t, n.GetTimeDefault(time.Time{}, time.RFC3339, "user").IsZero(), true)
+}
+
+func second(_ interface{}, err error) error {
+	return err
+}
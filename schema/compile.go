@@ -0,0 +1,425 @@
+package schema
+
+import (
+	"math"
+	"math/big"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/alxarch/njson"
+	"github.com/alxarch/njson/numjson"
+	"github.com/alxarch/njson/strjson"
+)
+
+// Compile compiles a JSON Schema from a node.
+func Compile(n njson.Node) (*Schema, error) {
+	s := new(Schema)
+	c := compiler{
+		root:    n,
+		doc:     &s.doc,
+		schemas: make(map[string]*schema),
+		anchors: make(map[string]*schema),
+	}
+	root, err := c.compile(n, "")
+	if err != nil {
+		return nil, err
+	}
+	if err := c.resolve(); err != nil {
+		return nil, err
+	}
+	s.root = root
+	return s, nil
+}
+
+// MustCompile compiles a JSON Schema from a node and panics on error.
+func MustCompile(n njson.Node) *Schema {
+	s, err := Compile(n)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// CompileString parses and compiles a JSON Schema from a string.
+func CompileString(src string) (*Schema, error) {
+	d := njson.Blank()
+	defer d.Close()
+	n, _, err := d.Parse(src)
+	if err != nil {
+		return nil, err
+	}
+	return Compile(n)
+}
+
+type compiler struct {
+	root    njson.Node
+	doc     *njson.Document
+	schemas map[string]*schema
+	anchors map[string]*schema
+	refs    []*schema
+}
+
+func (c *compiler) errorf(loc, msg string) error {
+	return &CompileError{loc, msg}
+}
+
+func (c *compiler) compile(n njson.Node, loc string) (*schema, error) {
+	if s := c.schemas[loc]; s != nil {
+		return s, nil
+	}
+	s := newSchema(loc)
+	switch n.Type() {
+	case njson.TypeBoolean:
+		s.isBool = true
+		s.always, _ = n.ToBool()
+		c.schemas[loc] = s
+		return s, nil
+	case njson.TypeObject:
+	default:
+		return nil, c.errorf(loc, "Schema must be an object or a boolean")
+	}
+	c.schemas[loc] = s
+	var (
+		err  error
+		iter = n.Values()
+	)
+	for iter.Next() {
+		key := strjson.Unescaped(iter.Key())
+		loc := loc + "/" + escapeToken(key)
+		v := iter.Value()
+		switch key {
+		case "$schema", "$id", "$comment", "$vocabulary", "title", "description",
+			"default", "examples", "deprecated", "readOnly", "writeOnly",
+			"format", "contentEncoding", "contentMediaType", "contentSchema":
+			// Annotations
+		case "$defs", "definitions":
+			_, err = c.schemaMap(v, loc)
+		case "$anchor":
+			var name string
+			if name, err = c.string(v, loc); err == nil {
+				c.anchors[name] = s
+			}
+		case "$ref":
+			if s.ref, err = c.string(v, loc); err == nil {
+				c.refs = append(c.refs, s)
+			}
+		case "$dynamicRef", "$dynamicAnchor", "$recursiveRef", "$recursiveAnchor",
+			"unevaluatedItems", "unevaluatedProperties":
+			err = c.errorf(loc, "Unsupported keyword")
+		case "type":
+			err = c.compileType(s, v, loc)
+		case "enum":
+			if v.Type() != njson.TypeArray {
+				err = c.errorf(loc, "Value must be an array")
+			} else {
+				s.enum = c.doc.Array()
+				for values := v.Values(); values.Next(); {
+					s.enum.Append(values.Value())
+				}
+				s.hasEnum = true
+			}
+		case "const":
+			s.constant = c.doc.Array()
+			s.constant.Append(v)
+			s.constant = s.constant.Index(0)
+			s.hasConst = true
+		case "multipleOf":
+			raw, _ := v.Data()
+			var f float64
+			if f, err = c.number(v, loc); err == nil {
+				if f <= 0 {
+					err = c.errorf(loc, "Value must be greater than 0")
+				} else if r, ok := new(big.Rat).SetString(raw); ok {
+					s.multipleOf = r
+				} else {
+					err = c.errorf(loc, "Value must be a number")
+				}
+			}
+		case "maximum":
+			s.maximum, err = c.number(v, loc)
+			s.hasMaximum = true
+		case "exclusiveMaximum":
+			s.exclusiveMaximum, err = c.number(v, loc)
+			s.hasExclusiveMax = true
+		case "minimum":
+			s.minimum, err = c.number(v, loc)
+			s.hasMinimum = true
+		case "exclusiveMinimum":
+			s.exclusiveMinimum, err = c.number(v, loc)
+			s.hasExclusiveMin = true
+		case "maxLength":
+			s.maxLength, err = c.count(v, loc)
+		case "minLength":
+			s.minLength, err = c.count(v, loc)
+		case "pattern":
+			s.pattern, err = c.regexp(v, loc)
+		case "maxItems":
+			s.maxItems, err = c.count(v, loc)
+		case "minItems":
+			s.minItems, err = c.count(v, loc)
+		case "uniqueItems":
+			var ok bool
+			if s.uniqueItems, ok = v.ToBool(); !ok {
+				err = c.errorf(loc, "Value must be a boolean")
+			}
+		case "maxContains":
+			s.maxContains, err = c.count(v, loc)
+		case "minContains":
+			s.minContains, err = c.count(v, loc)
+		case "maxProperties":
+			s.maxProperties, err = c.count(v, loc)
+		case "minProperties":
+			s.minProperties, err = c.count(v, loc)
+		case "required":
+			s.required, err = c.strings(v, loc)
+		case "dependentRequired":
+			err = c.compileDependentRequired(s, v, loc)
+		case "allOf":
+			s.allOf, err = c.schemaList(v, loc)
+		case "anyOf":
+			s.anyOf, err = c.schemaList(v, loc)
+		case "oneOf":
+			s.oneOf, err = c.schemaList(v, loc)
+		case "not":
+			s.not, err = c.compile(v, loc)
+		case "if":
+			s.ifSchema, err = c.compile(v, loc)
+		case "then":
+			s.thenSchema, err = c.compile(v, loc)
+		case "else":
+			s.elseSchema, err = c.compile(v, loc)
+		case "dependentSchemas":
+			s.dependentSchemas, err = c.schemaMap(v, loc)
+		case "prefixItems":
+			s.prefixItems, err = c.schemaList(v, loc)
+		case "items":
+			s.items, err = c.compile(v, loc)
+		case "contains":
+			s.contains, err = c.compile(v, loc)
+		case "properties":
+			s.properties, err = c.schemaMap(v, loc)
+		case "patternProperties":
+			err = c.compilePatternProperties(s, v, loc)
+		case "additionalProperties":
+			s.additionalProperties, err = c.compile(v, loc)
+		case "propertyNames":
+			s.propertyNames, err = c.compile(v, loc)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+var typeNames = map[string]njson.Type{
+	"string":  njson.TypeString,
+	"number":  njson.TypeNumber,
+	"integer": njson.TypeNumber,
+	"object":  njson.TypeObject,
+	"array":   njson.TypeArray,
+	"boolean": njson.TypeBoolean,
+	"null":    njson.TypeNull,
+}
+
+func (c *compiler) compileType(s *schema, n njson.Node, loc string) error {
+	var names []string
+	switch n.Type() {
+	case njson.TypeString:
+		names = []string{n.Unescaped()}
+	case njson.TypeArray:
+		var err error
+		if names, err = c.strings(n, loc); err != nil {
+			return err
+		}
+	default:
+		return c.errorf(loc, "Value must be a string or an array of strings")
+	}
+	number, integer := false, false
+	for _, name := range names {
+		t, ok := typeNames[name]
+		if !ok {
+			return c.errorf(loc, "Unknown type "+strconv.Quote(name))
+		}
+		switch name {
+		case "number":
+			number = true
+		case "integer":
+			integer = true
+		}
+		s.types |= t
+	}
+	s.integer = integer && !number
+	return nil
+}
+
+func (c *compiler) compileDependentRequired(s *schema, n njson.Node, loc string) error {
+	if n.Type() != njson.TypeObject {
+		return c.errorf(loc, "Value must be an object")
+	}
+	for iter := n.Values(); iter.Next(); {
+		key := strjson.Unescaped(iter.Key())
+		required, err := c.strings(iter.Value(), loc+"/"+escapeToken(key))
+		if err != nil {
+			return err
+		}
+		s.dependentRequired = append(s.dependentRequired, dependency{key, required})
+	}
+	return nil
+}
+
+func (c *compiler) compilePatternProperties(s *schema, n njson.Node, loc string) error {
+	if n.Type() != njson.TypeObject {
+		return c.errorf(loc, "Value must be an object")
+	}
+	for iter := n.Values(); iter.Next(); {
+		key := strjson.Unescaped(iter.Key())
+		loc := loc + "/" + escapeToken(key)
+		rx, err := regexp.Compile(key)
+		if err != nil {
+			return c.errorf(loc, err.Error())
+		}
+		sub, err := c.compile(iter.Value(), loc)
+		if err != nil {
+			return err
+		}
+		s.patternProperties = append(s.patternProperties, patternProperty{rx, sub})
+	}
+	return nil
+}
+
+func (c *compiler) schemaList(n njson.Node, loc string) ([]*schema, error) {
+	if n.Type() != njson.TypeArray {
+		return nil, c.errorf(loc, "Value must be an array of schemas")
+	}
+	iter := n.Values()
+	list := make([]*schema, 0, iter.Len())
+	for iter.Next() {
+		s, err := c.compile(iter.Value(), loc+"/"+strconv.Itoa(iter.Index()))
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, s)
+	}
+	return list, nil
+}
+
+func (c *compiler) schemaMap(n njson.Node, loc string) ([]property, error) {
+	if n.Type() != njson.TypeObject {
+		return nil, c.errorf(loc, "Value must be an object of schemas")
+	}
+	iter := n.Values()
+	props := make([]property, 0, iter.Len())
+	for iter.Next() {
+		key := strjson.Unescaped(iter.Key())
+		s, err := c.compile(iter.Value(), loc+"/"+escapeToken(key))
+		if err != nil {
+			return nil, err
+		}
+		props = append(props, property{key, s})
+	}
+	return props, nil
+}
+
+func (c *compiler) string(n njson.Node, loc string) (string, error) {
+	if n.Type() != njson.TypeString {
+		return "", c.errorf(loc, "Value must be a string")
+	}
+	return n.Unescaped(), nil
+}
+
+func (c *compiler) strings(n njson.Node, loc string) ([]string, error) {
+	if n.Type() != njson.TypeArray {
+		return nil, c.errorf(loc, "Value must be an array of strings")
+	}
+	iter := n.Values()
+	values := make([]string, 0, iter.Len())
+	for iter.Next() {
+		v := iter.Value()
+		if v.Type() != njson.TypeString {
+			return nil, c.errorf(loc+"/"+strconv.Itoa(iter.Index()), "Value must be a string")
+		}
+		values = append(values, v.Unescaped())
+	}
+	return values, nil
+}
+
+func (c *compiler) number(n njson.Node, loc string) (float64, error) {
+	if raw, typ := n.Data(); typ == njson.TypeNumber {
+		if f := numjson.ParseFloat(raw); f == f {
+			return f, nil
+		}
+	}
+	return 0, c.errorf(loc, "Value must be a number")
+}
+
+func (c *compiler) count(n njson.Node, loc string) (int, error) {
+	f, err := c.number(n, loc)
+	if err != nil {
+		return 0, err
+	}
+	if f < 0 || f > math.MaxInt32 || math.Trunc(f) != f {
+		return 0, c.errorf(loc, "Value must be a non-negative integer")
+	}
+	return int(f), nil
+}
+
+func (c *compiler) regexp(n njson.Node, loc string) (*regexp.Regexp, error) {
+	s, err := c.string(n, loc)
+	if err != nil {
+		return nil, err
+	}
+	rx, err := regexp.Compile(s)
+	if err != nil {
+		return nil, c.errorf(loc, err.Error())
+	}
+	return rx, nil
+}
+
+// resolve links all $ref keywords to their target schemas.
+// Resolving a reference might compile new schemas with more references.
+func (c *compiler) resolve() error {
+	for len(c.refs) > 0 {
+		s := c.refs[0]
+		c.refs = c.refs[1:]
+		target, err := c.lookup(s.ref, s.loc+"/$ref")
+		if err != nil {
+			return err
+		}
+		s.target = target
+	}
+	return nil
+}
+
+func (c *compiler) lookup(ref, loc string) (*schema, error) {
+	if !strings.HasPrefix(ref, "#") {
+		return nil, c.errorf(loc, "Unsupported non-local reference "+strconv.Quote(ref))
+	}
+	fragment, err := url.PathUnescape(ref[1:])
+	if err != nil {
+		return nil, c.errorf(loc, err.Error())
+	}
+	if fragment != "" && fragment[0] != '/' {
+		if s := c.anchors[fragment]; s != nil {
+			return s, nil
+		}
+		return nil, c.errorf(loc, "Unresolved reference "+strconv.Quote(ref))
+	}
+	if s := c.schemas[fragment]; s != nil {
+		return s, nil
+	}
+	var path []string
+	if fragment != "" {
+		path = strings.Split(fragment[1:], "/")
+		for i, token := range path {
+			path[i] = pointerUnescaper.Replace(token)
+		}
+	}
+	n := c.root.Lookup(path...)
+	if n.Type() == njson.TypeInvalid {
+		return nil, c.errorf(loc, "Unresolved reference "+strconv.Quote(ref))
+	}
+	return c.compile(n, fragment)
+}
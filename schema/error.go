@@ -0,0 +1,60 @@
+package schema
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Error is a schema violation.
+type Error struct {
+	Path    string // JSON Pointer to the invalid value in the instance
+	Keyword string // JSON Pointer to the failed keyword in the schema
+	Message string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("Invalid value at %q: %s", e.Path, e.Message)
+}
+
+// Errors is a list of schema violations.
+type Errors []Error
+
+func (e Errors) Error() string {
+	switch len(e) {
+	case 0:
+		return "No errors"
+	case 1:
+		return e[0].Error()
+	default:
+		msg := make([]string, len(e))
+		for i := range e {
+			msg[i] = e[i].Error()
+		}
+		return fmt.Sprintf("%d errors: %s", len(e), strings.Join(msg, "; "))
+	}
+}
+
+// CompileError signifies an invalid schema document.
+type CompileError struct {
+	Keyword string // JSON Pointer to the invalid keyword in the schema
+	Message string
+}
+
+func (e *CompileError) Error() string {
+	return fmt.Sprintf("Invalid schema at %q: %s", e.Keyword, e.Message)
+}
+
+var errNilSchema = errors.New("Nil schema")
+
+var pointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+
+var pointerUnescaper = strings.NewReplacer("~1", "/", "~0", "~")
+
+// escapeToken escapes a JSON Pointer reference token.
+func escapeToken(s string) string {
+	if strings.IndexByte(s, '~') == -1 && strings.IndexByte(s, '/') == -1 {
+		return s
+	}
+	return pointerEscaper.Replace(s)
+}
@@ -0,0 +1,150 @@
+// Package schema validates `njson.Node` values against JSON Schema documents.
+//
+// It supports the core and validation vocabularies of JSON Schema draft 2020-12.
+// References are resolved only within the same schema document (`#`, `#/$defs/foo`, `#anchor`).
+// The `unevaluatedItems`, `unevaluatedProperties` and `$dynamicRef` keywords are not supported.
+// The `format` keyword is treated as an annotation and is not validated.
+//
+// Schemas and instances are read directly from `njson.Node` values
+// without converting them to `interface{}`.
+package schema
+
+import (
+	"math/big"
+	"regexp"
+
+	"github.com/alxarch/njson"
+)
+
+// Schema is a compiled JSON Schema.
+//
+// A Schema does not reference the document it was compiled from
+// so the document can be reset or reused after Compile returns.
+// A Schema is safe for concurrent use.
+type Schema struct {
+	root *schema
+	doc  njson.Document // holds copies of enum and const values
+}
+
+// schema is a compiled schema object.
+type schema struct {
+	loc    string // JSON Pointer to the schema in the schema document
+	always bool   // value of a boolean schema
+	isBool bool
+
+	ref    string
+	target *schema
+
+	types   njson.Type
+	integer bool // type includes "integer" but not "number"
+
+	enum     njson.Node
+	hasEnum  bool
+	constant njson.Node
+	hasConst bool
+
+	multipleOf       *big.Rat
+	maximum          float64
+	hasMaximum       bool
+	exclusiveMaximum float64
+	hasExclusiveMax  bool
+	minimum          float64
+	hasMinimum       bool
+	exclusiveMinimum float64
+	hasExclusiveMin  bool
+
+	maxLength int
+	minLength int
+	pattern   *regexp.Regexp
+
+	maxItems    int
+	minItems    int
+	uniqueItems bool
+	maxContains int
+	minContains int
+
+	maxProperties     int
+	minProperties     int
+	required          []string
+	dependentRequired []dependency
+
+	allOf            []*schema
+	anyOf            []*schema
+	oneOf            []*schema
+	not              *schema
+	ifSchema         *schema
+	thenSchema       *schema
+	elseSchema       *schema
+	dependentSchemas []property
+
+	prefixItems []*schema
+	items       *schema
+	contains    *schema
+
+	properties           []property
+	patternProperties    []patternProperty
+	additionalProperties *schema
+	propertyNames        *schema
+}
+
+type property struct {
+	name   string
+	schema *schema
+}
+
+type patternProperty struct {
+	pattern *regexp.Regexp
+	schema  *schema
+}
+
+type dependency struct {
+	name     string
+	required []string
+}
+
+func newSchema(loc string) *schema {
+	return &schema{
+		loc:         loc,
+		maxLength:   -1,
+		minLength:   -1,
+		maxItems:    -1,
+		minItems:    -1,
+		maxContains: -1,
+		minContains: -1,
+
+		maxProperties: -1,
+		minProperties: -1,
+	}
+}
+
+func (s *schema) property(name string) *schema {
+	for i := range s.properties {
+		if p := &s.properties[i]; p.name == name {
+			return p.schema
+		}
+	}
+	return nil
+}
+
+// Validate validates a node against the schema.
+//
+// If the node is not valid it returns an Errors value listing all violations.
+func (s *Schema) Validate(n njson.Node) error {
+	if s == nil || s.root == nil {
+		return errNilSchema
+	}
+	v := validator{}
+	if v.validate(s.root, n) {
+		return nil
+	}
+	return v.errors
+}
+
+// IsValid checks if a node is valid without collecting errors.
+func (s *Schema) IsValid(n njson.Node) bool {
+	if s == nil || s.root == nil {
+		return false
+	}
+	v := validator{quick: true}
+	return v.validate(s.root, n)
+}
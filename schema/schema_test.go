@@ -0,0 +1,176 @@
+package schema
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/alxarch/njson"
+)
+
+func assertNoError(t *testing.T, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+}
+
+func assertEqual(t *testing.T, a, b interface{}) {
+	t.Helper()
+	if !reflect.DeepEqual(a, b) {
+		t.Fatalf("Assertion failed: %v != %v", a, b)
+	}
+}
+
+func TestSchema_Validate(t *testing.T) {
+	for _, tc := range []struct {
+		schema string
+		input  string
+		valid  bool
+	}{
+		{`true`, `42`, true},
+		{`false`, `42`, false},
+		{`{}`, `{"foo":[1,2]}`, true},
+		{`{"type":"string"}`, `"foo"`, true},
+		{`{"type":"string"}`, `42`, false},
+		{`{"type":["string","null"]}`, `null`, true},
+		{`{"type":"integer"}`, `42.0`, true},
+		{`{"type":"integer"}`, `42.5`, false},
+		{`{"type":["integer","number"]}`, `42.5`, true},
+		{`{"enum":[1,"foo",{"bar":null}]}`, `1.0`, true},
+		{`{"enum":[1,"foo",{"bar":null}]}`, `{"bar":null}`, true},
+		{`{"enum":[1,"foo",{"bar":null}]}`, `"bar"`, false},
+		{`{"const":"é"}`, `"é"`, true},
+		{`{"multipleOf":0.0001}`, `0.0075`, true},
+		{`{"multipleOf":2}`, `7`, false},
+		{`{"maximum":3}`, `3`, true},
+		{`{"exclusiveMaximum":3}`, `3`, false},
+		{`{"minimum":3}`, `2`, false},
+		{`{"exclusiveMinimum":3}`, `3.1`, true},
+		{`{"maxLength":2}`, `"世界"`, true},
+		{`{"minLength":3}`, `"世界"`, false},
+		{`{"pattern":"^a+$"}`, `"aaa"`, true},
+		{`{"pattern":"^a+$"}`, `"aba"`, false},
+		{`{"maxItems":1}`, `[1,2]`, false},
+		{`{"minItems":1}`, `[]`, false},
+		{`{"uniqueItems":true}`, `[1,{"a":1,"b":2},{"b":2,"a":1}]`, false},
+		{`{"uniqueItems":true}`, `[1,"1",[1]]`, true},
+		{`{"prefixItems":[{"type":"string"}],"items":{"type":"number"}}`, `["foo",1,2]`, true},
+		{`{"prefixItems":[{"type":"string"}],"items":{"type":"number"}}`, `["foo",1,"bar"]`, false},
+		{`{"contains":{"type":"string"}}`, `[1,2]`, false},
+		{`{"contains":{"type":"string"},"minContains":2}`, `["a",2,"b"]`, true},
+		{`{"contains":{"type":"string"},"maxContains":1}`, `["a",2,"b"]`, false},
+		{`{"maxProperties":1}`, `{"a":1,"b":2}`, false},
+		{`{"minProperties":1}`, `{}`, false},
+		{`{"required":["a","b"]}`, `{"a":1,"b":2}`, true},
+		{`{"required":["a","b"]}`, `{"a":1}`, false},
+		{`{"dependentRequired":{"a":["b"]}}`, `{"a":1}`, false},
+		{`{"dependentRequired":{"a":["b"]}}`, `{"c":1}`, true},
+		{`{"dependentSchemas":{"a":{"required":["b"]}}}`, `{"a":1}`, false},
+		{`{"properties":{"a":{"type":"string"}}}`, `{"a":"foo","b":1}`, true},
+		{`{"properties":{"a":{"type":"string"}}}`, `{"a":1}`, false},
+		{`{"patternProperties":{"^x-":{"type":"string"}},"additionalProperties":false}`, `{"x-foo":"bar"}`, true},
+		{`{"patternProperties":{"^x-":{"type":"string"}},"additionalProperties":false}`, `{"foo":"bar"}`, false},
+		{`{"propertyNames":{"maxLength":3}}`, `{"foo":1,"barbaz":2}`, false},
+		{`{"allOf":[{"type":"number"},{"minimum":2}]}`, `1`, false},
+		{`{"anyOf":[{"type":"string"},{"minimum":2}]}`, `3`, true},
+		{`{"anyOf":[{"type":"string"},{"minimum":2}]}`, `1`, false},
+		{`{"oneOf":[{"type":"number"},{"minimum":2}]}`, `3`, false},
+		{`{"oneOf":[{"type":"number"},{"minimum":2}]}`, `1`, true},
+		{`{"not":{"type":"null"}}`, `null`, false},
+		{`{"if":{"type":"string"},"then":{"minLength":2},"else":{"type":"number"}}`, `"a"`, false},
+		{`{"if":{"type":"string"},"then":{"minLength":2},"else":{"type":"number"}}`, `true`, false},
+		{`{"if":{"type":"string"},"then":{"minLength":2},"else":{"type":"number"}}`, `2`, true},
+		{`{"$defs":{"pos":{"minimum":0}},"items":{"$ref":"#/$defs/pos"}}`, `[1,2,-1]`, false},
+		{`{"$defs":{"pos":{"$anchor":"pos","minimum":0}},"items":{"$ref":"#pos"}}`, `[1,2]`, true},
+		{`{"properties":{"next":{"$ref":"#"}},"type":"object"}`, `{"next":{"next":{}}}`, true},
+		{`{"properties":{"next":{"$ref":"#"}},"type":"object"}`, `{"next":{"next":1}}`, false},
+		{`{"$ref":"#"}`, `1`, true},
+		{`{"anyOf":[{"$ref":"#"}],"type":"number"}`, `1`, true},
+		{`{"anyOf":[{"$ref":"#"}],"type":"number"}`, `"a"`, false},
+		{`{"$defs":{"a":{"$ref":"#/$defs/b"},"b":{"$ref":"#/$defs/a","minimum":0}},"$ref":"#/$defs/a"}`, `-1`, false},
+	} {
+		d := njson.Document{}
+		root, _, err := d.Parse(tc.schema)
+		assertNoError(t, err)
+		s, err := Compile(root)
+		assertNoError(t, err)
+		// Schema must not depend on the schema document
+		d.Reset()
+		n, _, err := d.Parse(tc.input)
+		assertNoError(t, err)
+		if err := s.Validate(n); (err == nil) != tc.valid {
+			t.Errorf("Invalid result for %s %s: %v", tc.schema, tc.input, err)
+		}
+		if s.IsValid(n) != tc.valid {
+			t.Errorf("Invalid IsValid result for %s %s", tc.schema, tc.input)
+		}
+	}
+}
+
+func TestSchema_Errors(t *testing.T) {
+	s, err := CompileString(`{
+		"type": "object",
+		"properties": {
+			"orders": {
+				"type": "array",
+				"items": {"$ref": "#/$defs/order"}
+			}
+		},
+		"$defs": {
+			"order": {
+				"required": ["id"],
+				"properties": {
+					"id": {"type": "integer"},
+					"a/b": {"maxLength": 1}
+				}
+			}
+		}
+	}`)
+	assertNoError(t, err)
+	d := njson.Document{}
+	n, _, err := d.Parse(`{"orders":[{"id":1},{"id":"2","a/b":"foo"},{}]}`)
+	assertNoError(t, err)
+	err = s.Validate(n)
+	errs, ok := err.(Errors)
+	if !ok {
+		t.Fatalf("Invalid error %v", err)
+	}
+	assertEqual(t, errs, Errors{
+		{"/orders/1/id", "/$defs/order/properties/id/type", "Invalid type String not in [Integer]"},
+		{"/orders/1/a~1b", "/$defs/order/properties/a~1b/maxLength", "String length 3 is greater than 1"},
+		{"/orders/2", "/$defs/order/required", `Missing required property "id"`},
+	})
+}
+
+func TestSchema_UniqueItemsErrors(t *testing.T) {
+	s, err := CompileString(`{"uniqueItems":true}`)
+	assertNoError(t, err)
+	d := njson.Document{}
+	n, _, err := d.Parse(`[1,1,2,1,2]`)
+	assertNoError(t, err)
+	// Each duplicate is reported once
+	assertEqual(t, s.Validate(n), Errors{
+		{"", "/uniqueItems", "Array item 1 is equal to item 0"},
+		{"", "/uniqueItems", "Array item 3 is equal to item 0"},
+		{"", "/uniqueItems", "Array item 4 is equal to item 2"},
+	})
+}
+
+func TestCompile_Invalid(t *testing.T) {
+	for _, src := range []string{
+		`42`,
+		`{"type":"foo"}`,
+		`{"minLength":-1}`,
+		`{"pattern":"("}`,
+		`{"items":{"$ref":"#/$defs/missing"}}`,
+		`{"$ref":"http://example.com/schema.json"}`,
+		`{"enum":"foo"}`,
+		`{"unevaluatedProperties":false}`,
+	} {
+		if _, err := CompileString(src); err == nil {
+			t.Errorf("Expected compile error for %s", src)
+		} else if _, ok := err.(*CompileError); !ok {
+			t.Errorf("Invalid compile error for %s: %v", src, err)
+		}
+	}
+}
@@ -0,0 +1,432 @@
+package schema
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/alxarch/njson"
+	"github.com/alxarch/njson/numjson"
+	"github.com/alxarch/njson/strjson"
+)
+
+type validator struct {
+	errors Errors
+	path   []string       // escaped JSON Pointer tokens of the current instance location
+	quick  bool           // do not collect errors
+	keys   njson.Document // scratch document for propertyNames
+	refs   []refFrame     // $ref targets applied to instances on the stack
+}
+
+// refFrame is a $ref target applied to an instance.
+// Applying the same target to the same instance again is a cycle.
+type refFrame struct {
+	target *schema
+	doc    *njson.Document
+	id     uint
+}
+
+// enterRef pushes a $ref frame and reports false if it is already on the stack.
+func (v *validator) enterRef(s *schema, n njson.Node) bool {
+	frame := refFrame{s.target, n.Document(), n.ID()}
+	for _, f := range v.refs {
+		if f == frame {
+			return false
+		}
+	}
+	v.refs = append(v.refs, frame)
+	return true
+}
+
+func (v *validator) errorf(s *schema, keyword, format string, args ...interface{}) {
+	if v.quick {
+		return
+	}
+	var path string
+	if len(v.path) > 0 {
+		path = "/" + strings.Join(v.path, "/")
+	}
+	v.errors = append(v.errors, Error{
+		Path:    path,
+		Keyword: s.loc + "/" + keyword,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+func (v *validator) push(token string) {
+	v.path = append(v.path, token)
+}
+
+func (v *validator) pop() {
+	if n := len(v.path) - 1; 0 <= n && n < len(v.path) {
+		v.path = v.path[:n]
+	}
+}
+
+// test checks if a node is valid against a schema without collecting errors.
+func (v *validator) test(s *schema, n njson.Node) bool {
+	sub := validator{quick: true, refs: v.refs}
+	return sub.validate(s, n)
+}
+
+func (v *validator) validate(s *schema, n njson.Node) bool {
+	if s.isBool {
+		if !s.always {
+			v.errorf(s, "", "No value is allowed")
+		}
+		return s.always
+	}
+	ok := true
+	// A cycle adds no constraints other than the ones already being checked
+	if s.target != nil && v.enterRef(s, n) {
+		ok = v.validate(s.target, n)
+		v.refs = v.refs[:len(v.refs)-1]
+	}
+	raw, typ := n.Data()
+	if typ == njson.TypeInvalid {
+		v.errorf(s, "", "Invalid node")
+		return false
+	}
+	if s.types != 0 {
+		if s.types&typ == 0 || (s.integer && typ == njson.TypeNumber && !isInteger(raw)) {
+			v.errorf(s, "type", "Invalid type %s not in %s", typeName(typ, raw), typeList(s))
+			ok = false
+		}
+	}
+	if s.hasConst && !equal(s.constant, n) {
+		v.errorf(s, "const", "Value does not match const")
+		ok = false
+	}
+	if s.hasEnum && !v.inEnum(s, n) {
+		v.errorf(s, "enum", "Value is not one of the enum values")
+		ok = false
+	}
+	switch typ {
+	case njson.TypeNumber:
+		ok = v.validateNumber(s, raw) && ok
+	case njson.TypeString:
+		ok = v.validateString(s, raw) && ok
+	case njson.TypeArray:
+		ok = v.validateArray(s, n) && ok
+	case njson.TypeObject:
+		ok = v.validateObject(s, n) && ok
+	}
+	ok = v.validateApplicators(s, n) && ok
+	return ok
+}
+
+func (v *validator) inEnum(s *schema, n njson.Node) bool {
+	for iter := s.enum.Values(); iter.Next(); {
+		if equal(iter.Value(), n) {
+			return true
+		}
+	}
+	return false
+}
+
+func (v *validator) validateApplicators(s *schema, n njson.Node) bool {
+	ok := true
+	for _, sub := range s.allOf {
+		ok = v.validate(sub, n) && ok
+	}
+	if len(s.anyOf) > 0 {
+		match := false
+		for _, sub := range s.anyOf {
+			if v.test(sub, n) {
+				match = true
+				break
+			}
+		}
+		if !match {
+			v.errorf(s, "anyOf", "Value does not match any schema")
+			ok = false
+		}
+	}
+	if len(s.oneOf) > 0 {
+		matches := 0
+		for _, sub := range s.oneOf {
+			if v.test(sub, n) {
+				matches++
+			}
+		}
+		if matches != 1 {
+			v.errorf(s, "oneOf", "Value matches %d schemas instead of exactly one", matches)
+			ok = false
+		}
+	}
+	if s.not != nil && v.test(s.not, n) {
+		v.errorf(s, "not", "Value must not match schema")
+		ok = false
+	}
+	if s.ifSchema != nil {
+		if v.test(s.ifSchema, n) {
+			if s.thenSchema != nil {
+				ok = v.validate(s.thenSchema, n) && ok
+			}
+		} else if s.elseSchema != nil {
+			ok = v.validate(s.elseSchema, n) && ok
+		}
+	}
+	return ok
+}
+
+func (v *validator) validateNumber(s *schema, raw string) bool {
+	ok := true
+	f := numjson.ParseFloat(raw)
+	if f != f {
+		v.errorf(s, "", "Invalid number %q", raw)
+		return false
+	}
+	if s.hasMaximum && f > s.maximum {
+		v.errorf(s, "maximum", "Value %s is greater than %v", raw, s.maximum)
+		ok = false
+	}
+	if s.hasExclusiveMax && f >= s.exclusiveMaximum {
+		v.errorf(s, "exclusiveMaximum", "Value %s is not less than %v", raw, s.exclusiveMaximum)
+		ok = false
+	}
+	if s.hasMinimum && f < s.minimum {
+		v.errorf(s, "minimum", "Value %s is less than %v", raw, s.minimum)
+		ok = false
+	}
+	if s.hasExclusiveMin && f <= s.exclusiveMinimum {
+		v.errorf(s, "exclusiveMinimum", "Value %s is not greater than %v", raw, s.exclusiveMinimum)
+		ok = false
+	}
+	if s.multipleOf != nil {
+		if r, valid := new(big.Rat).SetString(raw); !valid || !r.Quo(r, s.multipleOf).IsInt() {
+			v.errorf(s, "multipleOf", "Value %s is not a multiple of %s", raw, s.multipleOf.RatString())
+			ok = false
+		}
+	}
+	return ok
+}
+
+func (v *validator) validateString(s *schema, raw string) bool {
+	if s.maxLength == -1 && s.minLength == -1 && s.pattern == nil {
+		return true
+	}
+	ok := true
+	str := strjson.Unescaped(raw)
+	size := utf8.RuneCountInString(str)
+	if s.maxLength != -1 && size > s.maxLength {
+		v.errorf(s, "maxLength", "String length %d is greater than %d", size, s.maxLength)
+		ok = false
+	}
+	if s.minLength != -1 && size < s.minLength {
+		v.errorf(s, "minLength", "String length %d is less than %d", size, s.minLength)
+		ok = false
+	}
+	if s.pattern != nil && !s.pattern.MatchString(str) {
+		v.errorf(s, "pattern", "String does not match pattern %q", s.pattern.String())
+		ok = false
+	}
+	return ok
+}
+
+func (v *validator) validateArray(s *schema, n njson.Node) bool {
+	ok := true
+	iter := n.Values()
+	size := iter.Len()
+	if s.maxItems != -1 && size > s.maxItems {
+		v.errorf(s, "maxItems", "Array length %d is greater than %d", size, s.maxItems)
+		ok = false
+	}
+	if s.minItems != -1 && size < s.minItems {
+		v.errorf(s, "minItems", "Array length %d is less than %d", size, s.minItems)
+		ok = false
+	}
+	if s.uniqueItems {
+		for j := 1; j < size; j++ {
+			for i := 0; i < j; i++ {
+				if equal(n.Index(i), n.Index(j)) {
+					v.errorf(s, "uniqueItems", "Array item %d is equal to item %d", j, i)
+					ok = false
+					break
+				}
+			}
+		}
+	}
+	matches := 0
+	for iter.Next() {
+		i := iter.Index()
+		el := iter.Value()
+		v.push(strconv.Itoa(i))
+		if i < len(s.prefixItems) {
+			ok = v.validate(s.prefixItems[i], el) && ok
+		} else if s.items != nil {
+			ok = v.validate(s.items, el) && ok
+		}
+		v.pop()
+		if s.contains != nil && v.test(s.contains, el) {
+			matches++
+		}
+	}
+	if s.contains != nil {
+		minContains := 1
+		if s.minContains != -1 {
+			minContains = s.minContains
+		}
+		if matches < minContains {
+			v.errorf(s, "contains", "Array contains %d matching items instead of at least %d", matches, minContains)
+			ok = false
+		}
+		if s.maxContains != -1 && matches > s.maxContains {
+			v.errorf(s, "maxContains", "Array contains %d matching items instead of at most %d", matches, s.maxContains)
+			ok = false
+		}
+	}
+	return ok
+}
+
+func (v *validator) validateObject(s *schema, n njson.Node) bool {
+	ok := true
+	iter := n.Values()
+	size := iter.Len()
+	if s.maxProperties != -1 && size > s.maxProperties {
+		v.errorf(s, "maxProperties", "Object has %d properties instead of at most %d", size, s.maxProperties)
+		ok = false
+	}
+	if s.minProperties != -1 && size < s.minProperties {
+		v.errorf(s, "minProperties", "Object has %d properties instead of at least %d", size, s.minProperties)
+		ok = false
+	}
+	for _, name := range s.required {
+		if !hasKey(n, name) {
+			v.errorf(s, "required", "Missing required property %q", name)
+			ok = false
+		}
+	}
+	for _, dep := range s.dependentRequired {
+		if !hasKey(n, dep.name) {
+			continue
+		}
+		for _, name := range dep.required {
+			if !hasKey(n, name) {
+				v.errorf(s, "dependentRequired", "Missing property %q required by %q", name, dep.name)
+				ok = false
+			}
+		}
+	}
+	for _, dep := range s.dependentSchemas {
+		if hasKey(n, dep.name) {
+			ok = v.validate(dep.schema, n) && ok
+		}
+	}
+	for iter.Next() {
+		key := strjson.Unescaped(iter.Key())
+		value := iter.Value()
+		v.push(escapeToken(key))
+		matched := false
+		if sub := s.property(key); sub != nil {
+			matched = true
+			ok = v.validate(sub, value) && ok
+		}
+		for i := range s.patternProperties {
+			if p := &s.patternProperties[i]; p.pattern.MatchString(key) {
+				matched = true
+				ok = v.validate(p.schema, value) && ok
+			}
+		}
+		if !matched && s.additionalProperties != nil {
+			ok = v.validate(s.additionalProperties, value) && ok
+		}
+		if s.propertyNames != nil {
+			v.keys.Reset()
+			ok = v.validate(s.propertyNames, v.keys.TextRaw(iter.Key())) && ok
+		}
+		v.pop()
+	}
+	return ok
+}
+
+// hasKey checks if an object node has a key in unescaped form.
+func hasKey(n njson.Node, name string) bool {
+	for iter := n.Values(); iter.Next(); {
+		if strjson.Unescaped(iter.Key()) == name {
+			return true
+		}
+	}
+	return false
+}
+
+func isInteger(raw string) bool {
+	f := numjson.ParseFloat(raw)
+	return f == f && !math.IsInf(f, 0) && math.Trunc(f) == f
+}
+
+func typeName(typ njson.Type, raw string) string {
+	if typ == njson.TypeNumber && isInteger(raw) {
+		return "Integer"
+	}
+	return typ.String()
+}
+
+func typeList(s *schema) string {
+	types := s.types.Types()
+	names := make([]string, len(types))
+	for i, t := range types {
+		if t == njson.TypeNumber && s.integer {
+			names[i] = "Integer"
+		} else {
+			names[i] = t.String()
+		}
+	}
+	return "[" + strings.Join(names, " ") + "]"
+}
+
+// equal checks if two nodes are equal JSON values.
+func equal(a, b njson.Node) bool {
+	rawA, typ := a.Data()
+	rawB, typB := b.Data()
+	if typ != typB {
+		return false
+	}
+	switch typ {
+	case njson.TypeNumber:
+		if rawA == rawB {
+			return true
+		}
+		x, y := numjson.ParseFloat(rawA), numjson.ParseFloat(rawB)
+		return x == y
+	case njson.TypeString:
+		return rawA == rawB || strjson.Unescaped(rawA) == strjson.Unescaped(rawB)
+	case njson.TypeArray:
+		x, y := a.Values(), b.Values()
+		if x.Len() != y.Len() {
+			return false
+		}
+		for x.Next() && y.Next() {
+			if !equal(x.Value(), y.Value()) {
+				return false
+			}
+		}
+		return true
+	case njson.TypeObject:
+		x, y := a.Values(), b.Values()
+		if x.Len() != y.Len() {
+			return false
+		}
+	next:
+		for x.Next() {
+			key := strjson.Unescaped(x.Key())
+			for y := b.Values(); y.Next(); {
+				if strjson.Unescaped(y.Key()) == key {
+					if equal(x.Value(), y.Value()) {
+						continue next
+					}
+					return false
+				}
+			}
+			return false
+		}
+		return true
+	case njson.TypeInvalid:
+		return false
+	default:
+		return rawA == rawB
+	}
+}
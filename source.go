@@ -0,0 +1,193 @@
+package njson
+
+import "sort"
+
+// sourceSlot is the input position of an object or array value.
+type sourceSlot struct {
+	sep    uint // position after the opening bracket or ','
+	key    uint // position of the opening quote of the key
+	keyEnd uint // position after the closing quote of the key
+	start  uint // start of the value
+	end    uint // end of the value
+}
+
+// sourceSlots finds the positions of the values in the source of an object or array node.
+// Values are found using the spans recorded for the nodes parsed in the object or array,
+// so only the space between values is scanned.
+// Positions are relative to the source of the object or array.
+func (d *Document) sourceSlots(id uint, sp *span, typ Type, slots []sourceSlot) []sourceSlot {
+	s := sp.src[sp.start:sp.end]
+	// Nodes parsed in the object or array follow it and their spans are in input order.
+	within := func(k uint, end uint) bool {
+		vs := &d.spans[k]
+		return vs.src == sp.src && sp.start < vs.start && vs.start < end
+	}
+	pos := uint(1)
+	for k := id + 1; k < uint(len(d.spans)) && within(k, sp.end); {
+		vs := &d.spans[k]
+		slot := sourceSlot{
+			sep:   pos,
+			start: vs.start - sp.start,
+			end:   vs.end - sp.start,
+		}
+		if typ == TypeObject {
+			slot.key = skipSpace(s, pos)
+			// Skip back over ':' and space before the value
+			end := skipSpaceBack(s, slot.start)
+			slot.keyEnd = skipSpaceBack(s, end-1)
+		}
+		slots = append(slots, slot)
+		// Skip ','
+		pos = skipSpace(s, slot.end) + 1
+		// Skip the nodes parsed in the value
+		next, end := k+1, vs.end
+		k = next + uint(sort.Search(len(d.spans)-int(next), func(i int) bool {
+			return !within(next+uint(i), end)
+		}))
+	}
+	return slots
+}
+
+func skipSpace(s string, pos uint) uint {
+	for pos < uint(len(s)) && bytemapIsSpace[s[pos]] == 1 {
+		pos++
+	}
+	return pos
+}
+
+// skipSpaceBack returns the position after the last non space byte before end.
+func skipSpaceBack(s string, end uint) uint {
+	for 0 < end && end <= uint(len(s)) && bytemapIsSpace[s[end-1]] == 1 {
+		end--
+	}
+	return end
+}
+
+// appendSource appends the JSON data of a node reusing the source text of
+// unmodified values and the formatting of objects and arrays.
+func (d *Document) appendSource(dst []byte, id uint) ([]byte, error) {
+	n := d.get(id)
+	if n == nil {
+		return dst, newTypeError(TypeInvalid, TypeAnyValue)
+	}
+	sp := d.span(id)
+	switch typ := n.info.Type(); typ {
+	case TypeObject, TypeArray:
+		return d.appendSourceValues(dst, id, n.values, typ, sp)
+	case TypeString:
+		if sp != nil && sp.end-sp.start == uint(len(n.raw))+2 && sp.src[sp.start+1:sp.end-1] == n.raw {
+			return append(dst, sp.src[sp.start:sp.end]...), nil
+		}
+	default:
+		if sp != nil && sp.src[sp.start:sp.end] == n.raw {
+			return append(dst, n.raw...), nil
+		}
+	}
+	return d.appendJSON(dst, n)
+}
+
+func (d *Document) appendSourceValues(dst []byte, id uint, values []V, typ Type, sp *span) ([]byte, error) {
+	var (
+		src   string
+		begin byte = delimBeginArray
+		end   byte = delimEndArray
+		err   error
+	)
+	if typ == TypeObject {
+		begin, end = delimBeginObject, delimEndObject
+	}
+	if sp != nil {
+		src = sp.src[sp.start:sp.end]
+	}
+	if src == "" || src[0] != begin {
+		// Not parsed as the same type, use compact formatting.
+		dst = append(dst, begin)
+		for i, v := range values {
+			if i > 0 {
+				dst = append(dst, delimValueSeparator)
+			}
+			if typ == TypeObject {
+				dst = append(dst, delimString)
+				dst = append(dst, v.key...)
+				dst = append(dst, delimString, delimNameSeparator)
+			}
+			if dst, err = d.appendSource(dst, v.id); err != nil {
+				return dst, err
+			}
+		}
+		return append(dst, end), nil
+	}
+
+	var (
+		buf   [8]sourceSlot
+		slots = d.sourceSlots(id, sp, typ, buf[:0])
+		next  int // next slot to match
+		last  = -1
+	)
+	dst = append(dst, begin)
+	for j, v := range values {
+		i := -1
+		// Search from the next slot first to match values in order quickly
+		for n := 0; n < len(slots); n++ {
+			k := (next + n) % len(slots)
+			slot := &slots[k]
+			if typ == TypeObject {
+				if src[slot.key+1:slot.keyEnd-1] == v.key {
+					i = k
+					break
+				}
+			} else if vs := d.span(v.id); vs != nil && vs.src == sp.src && vs.start == sp.start+slot.start {
+				i = k
+				break
+			}
+		}
+		switch {
+		case i == -1:
+			// New value, format like the next slot
+			if j > 0 {
+				dst = append(dst, delimValueSeparator)
+			}
+			if len(slots) == 0 {
+				if typ == TypeObject {
+					dst = append(dst, delimString)
+					dst = append(dst, v.key...)
+					dst = append(dst, delimString, delimNameSeparator)
+				}
+				break
+			}
+			slot := &slots[len(slots)-1]
+			if next < len(slots) {
+				slot = &slots[next]
+			}
+			if typ == TypeObject {
+				dst = append(dst, src[slot.sep:slot.key]...)
+				dst = append(dst, delimString)
+				dst = append(dst, v.key...)
+				dst = append(dst, delimString)
+				dst = append(dst, src[slot.keyEnd:slot.start]...)
+			} else {
+				dst = append(dst, src[slot.sep:slot.start]...)
+			}
+		case j > 0 && last != -1 && i == last+1:
+			// Contiguous values, reuse the original separator
+			dst = append(dst, src[slots[last].end:slots[i].start]...)
+		default:
+			if j > 0 {
+				dst = append(dst, delimValueSeparator)
+			}
+			dst = append(dst, src[slots[i].sep:slots[i].start]...)
+		}
+		if i != -1 {
+			next = i + 1
+		}
+		last = i
+		if dst, err = d.appendSource(dst, v.id); err != nil {
+			return dst, err
+		}
+	}
+	// Space before the closing bracket
+	if len(slots) > 0 {
+		return append(dst, src[slots[len(slots)-1].end:]...), nil
+	}
+	return append(dst, src[1:]...), nil
+}
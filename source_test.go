@@ -0,0 +1,127 @@
+package njson
+
+import (
+	"strings"
+	"testing"
+)
+
+const sourceConfig = `{
+  "name": "app",
+  "version" : 1.50,
+  "debug": false,
+  "tags": [ "a",  "b" ],
+  "db": {
+    "host": "localhost",
+    "port": 5432,
+    "opts": {}
+  },
+  "escaped": "é\/"
+}`
+
+func TestDocument_AppendJSONSource(t *testing.T) {
+	for _, tc := range []struct {
+		Name string
+		Edit func(n Node)
+		Want string
+	}{
+		{"unmodified", func(n Node) {}, sourceConfig},
+		{"set string", func(n Node) {
+			n.Lookup("db", "host").SetString("example.com")
+		}, strings.Replace(sourceConfig, `"localhost"`, `"example.com"`, 1)},
+		{"set number", func(n Node) {
+			n.Get("version").SetInt(2)
+		}, strings.Replace(sourceConfig, `1.50`, `2`, 1)},
+		{"same value", func(n Node) {
+			n.Get("escaped").SetStringRaw(`é\/`)
+		}, sourceConfig},
+		{"replace value", func(n Node) {
+			n.Set("debug", n.Document().True())
+		}, strings.Replace(sourceConfig, `"debug": false`, `"debug": true`, 1)},
+		{"add key", func(n Node) {
+			n.Get("db").Set("user", n.Document().Text("admin"))
+		}, strings.Replace(sourceConfig, `"opts": {}`, `"opts": {},
+    "user": "admin"`, 1)},
+		{"delete first key", func(n Node) {
+			n.Del("name")
+		}, strings.Replace(sourceConfig, `
+  "name": "app",`, ``, 1)},
+		{"strip key", func(n Node) {
+			n.Strip("host")
+			n.Strip("debug")
+		}, strings.Replace(strings.Replace(sourceConfig, `
+    "host": "localhost",`, ``, 1), `
+  "debug": false,`, ``, 1)},
+		{"delete middle key", func(n Node) {
+			n.Get("db").Del("port")
+		}, strings.Replace(sourceConfig, `
+    "port": 5432,`, ``, 1)},
+		{"delete last key", func(n Node) {
+			n.Del("escaped")
+		}, strings.Replace(sourceConfig, `,
+  "escaped": "é\/"`, ``, 1)},
+		{"append", func(n Node) {
+			n.Get("tags").Append(n.Document().Text("c"))
+		}, strings.Replace(sourceConfig, `[ "a",  "b" ]`, `[ "a",  "b",  "c" ]`, 1)},
+		{"remove", func(n Node) {
+			n.Get("tags").Remove(0)
+		}, strings.Replace(sourceConfig, `[ "a",  "b" ]`, `[  "b" ]`, 1)},
+		{"empty object", func(n Node) {
+			n.Lookup("db", "opts").Set("x", n.Document().Null())
+		}, strings.Replace(sourceConfig, `"opts": {}`, `"opts": {"x":null}`, 1)},
+		// Values copied to new nodes use compact formatting
+		{"new object", func(n Node) {
+			obj := n.Document().Object()
+			obj.Set("tags", n.Get("tags"))
+			n.Set("db", obj)
+		}, strings.Replace(sourceConfig, `{
+    "host": "localhost",
+    "port": 5432,
+    "opts": {}
+  }`, `{"tags":["a","b"]}`, 1)},
+	} {
+		t.Run(tc.Name, func(t *testing.T) {
+			d := Document{}
+			n, _, err := d.ParseSpans(sourceConfig)
+			assertNoError(t, err)
+			tc.Edit(n)
+			out, err := n.AppendJSON(nil)
+			assertNoError(t, err)
+			assertEqual(t, string(out), tc.Want)
+			out, err = d.AppendJSON(nil)
+			assertNoError(t, err)
+			assertEqual(t, string(out), tc.Want)
+		})
+	}
+}
+
+func TestDocument_AppendJSONSourceTestdata(t *testing.T) {
+	for _, src := range []string{
+		mediumJSON,
+		mediumJSONFormatted,
+		twitterJSON,
+		canadaJSON,
+	} {
+		d := Document{}
+		n, tail, err := d.ParseSpans(src)
+		assertNoError(t, err)
+		out, err := n.AppendJSON(nil)
+		assertNoError(t, err)
+		assertEqual(t, string(out)+tail, src)
+	}
+}
+
+func TestDocument_AppendJSONSourceMultiple(t *testing.T) {
+	d := Document{}
+	a, tail, err := d.ParseSpans(`[ 1, [ 2 , {"a" : [3]} ], 4 ] [5]`)
+	assertNoError(t, err)
+	b, _, err := d.ParseSpans(tail)
+	assertNoError(t, err)
+	a.Index(1).Index(1).Set("b", d.Text("c"))
+	b.Append(d.Text("6"))
+	out, err := a.AppendJSON(nil)
+	assertNoError(t, err)
+	assertEqual(t, string(out), `[ 1, [ 2 , {"a" : [3],"b" : "c"} ], 4 ]`)
+	out, err = b.AppendJSON(nil)
+	assertNoError(t, err)
+	assertEqual(t, string(out), `[5,"6"]`)
+}
@@ -0,0 +1,50 @@
+package strjson
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrInvalidString is the error for strings that are not valid JSON escaped strings.
+var ErrInvalidString = errors.New("Invalid string")
+
+// Validate checks if s is a valid JSON escaped string without the enclosing quotes.
+// It returns an error wrapping ErrInvalidString on unescaped quotes or control
+// characters and on invalid escape sequences.
+func Validate(s string) error {
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '"':
+			return fmt.Errorf("%w %q: unescaped quote at position %d", ErrInvalidString, s, i)
+		case c < 0x20:
+			return fmt.Errorf("%w %q: control character at position %d", ErrInvalidString, s, i)
+		case c != '\\':
+		case i+1 == len(s):
+			return fmt.Errorf("%w %q: unterminated escape at position %d", ErrInvalidString, s, i)
+		default:
+			i++
+			switch s[i] {
+			case '"', '\\', '/', 'b', 'f', 'n', 'r', 't':
+			case 'u':
+				if i+4 >= len(s) || !isHex(s[i+1:i+5]) {
+					return fmt.Errorf("%w %q: invalid unicode escape at position %d", ErrInvalidString, s, i-1)
+				}
+				i += 4
+			default:
+				return fmt.Errorf("%w %q: invalid escape at position %d", ErrInvalidString, s, i-1)
+			}
+		}
+	}
+	return nil
+}
+
+func isHex(s string) bool {
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case '0' <= c && c <= '9', 'a' <= c && c <= 'f', 'A' <= c && c <= 'F':
+		default:
+			return false
+		}
+	}
+	return true
+}
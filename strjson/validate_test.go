@@ -0,0 +1,34 @@
+package strjson
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	for _, s := range []string{
+		``,
+		`foo`,
+		`foo\"bar\\baz\/`,
+		`\b\f\n\r\t`,
+		`é😀`,
+		"é ",
+	} {
+		if err := Validate(s); err != nil {
+			t.Errorf("Validate(%q) unexpected error %s", s, err)
+		}
+	}
+	for _, s := range []string{
+		`foo"bar`,
+		"foo\nbar",
+		`foo\`,
+		`foo\x`,
+		`\u12`,
+		`\u12g4`,
+	} {
+		err := Validate(s)
+		if !errors.Is(err, ErrInvalidString) {
+			t.Errorf("Validate(%q) invalid error %v", s, err)
+		}
+	}
+}
@@ -87,6 +87,8 @@ const (
 const (
 	_ info = 1 << (iota + 8)
 	infRoot
+	infLazy    // Object or Array node with unparsed values in raw
+	infInvalid // Lazy node with invalid values in raw
 )
 
 // IsRoot checks if IsRoot flag is set.
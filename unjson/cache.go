@@ -11,23 +11,33 @@ type Cache struct {
 	mu       sync.RWMutex
 	decoders map[reflect.Type]Decoder
 	encoders map[reflect.Type]Encoder
+	registry *registry
+	dynamic  map[Options]*Cache
 }
 
 var defaultCache Cache
 
-// Decoder returns a Decoder for the type using the tag key from cache.Options
+// Decoder returns a Decoder for the type using cache.Options
 func (c *Cache) Decoder(typ reflect.Type) (dec Decoder, err error) {
 	c.mu.RLock()
 	dec = c.decoders[typ]
+	reg := c.registry
 	c.mu.RUnlock()
 	if dec != nil {
 		return
 	}
-	dec, err = NewTypeDecoder(typ, c.Tag)
+	options := c.Options.normalize()
+	options.registry = reg
+	dec, err = newTypeDecoder(typ, &options)
 	if err != nil {
 		return nil, err
 	}
 	c.mu.Lock()
+	if c.registry != reg {
+		// Functions were registered while building, do not cache a stale decoder
+		c.mu.Unlock()
+		return
+	}
 	if d := c.decoders[typ]; d != nil {
 		c.mu.Unlock()
 		return d, nil
@@ -44,15 +54,26 @@ func (c *Cache) Decoder(typ reflect.Type) (dec Decoder, err error) {
 func (c *Cache) Encoder(typ reflect.Type) (enc Encoder, err error) {
 	c.mu.RLock()
 	enc = c.encoders[typ]
+	reg := c.registry
 	c.mu.RUnlock()
 	if enc != nil {
 		return
 	}
-	enc, err = NewTypeEncoder(typ, c.Options)
+	options := c.Options.normalize()
+	options.registry = reg
+	if options.cache == nil {
+		options.cache = c
+	}
+	enc, err = newTypeEncoder(typ, &options)
 	if err != nil {
 		return nil, err
 	}
 	c.mu.Lock()
+	if c.registry != reg {
+		// Functions were registered while building, do not cache a stale encoder
+		c.mu.Unlock()
+		return
+	}
 	if e := c.encoders[typ]; e != nil {
 		c.mu.Unlock()
 		return e, nil
@@ -65,17 +86,76 @@ func (c *Cache) Encoder(typ reflect.Type) (enc Encoder, err error) {
 	return
 }
 
+// dynamicCaches holds a Cache for each Options used to encode the dynamic values of interfaces
+// with encoders that were not built by a Cache. These options have no registry so the keys are stable.
+var dynamicCaches sync.Map
+
+// dynamicCache returns the cache for encoding dynamic values of interfaces with the same options.
+func (o *Options) dynamicCache() *Cache {
+	if o == nil {
+		return &defaultCache
+	}
+	if o.cache != nil {
+		return o.cache.dynamicCache(*o)
+	}
+	if c, ok := dynamicCaches.Load(*o); ok {
+		return c.(*Cache)
+	}
+	c, _ := dynamicCaches.LoadOrStore(*o, &Cache{Options: *o})
+	return c.(*Cache)
+}
+
+// dynamicCache returns the cache for encoding dynamic values of interfaces with options derived from c.Options.
+// The caches are dropped when functions are registered on c.
+func (c *Cache) dynamicCache(o Options) *Cache {
+	c.mu.RLock()
+	d := c.dynamic[o]
+	reg := c.registry
+	c.mu.RUnlock()
+	if d != nil {
+		return d
+	}
+	d = &Cache{Options: o, registry: o.registry}
+	if o.registry != reg {
+		// Functions were registered since the options were created, do not cache
+		return d
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.registry != reg {
+		return d
+	}
+	if dd := c.dynamic[o]; dd != nil {
+		return dd
+	}
+	if c.dynamic == nil {
+		c.dynamic = make(map[Options]*Cache)
+	}
+	c.dynamic[o] = d
+	return d
+}
+
 // cache is used when creating new encoders/decoders to not recalculate stuff and avoid recursion issues.
 type cache map[cacheKey]interface{}
 
-// cacheKey is based on both Type and hint so types with different hints get correct encoders
+// cacheKey is based on Type, hint and time formats so types with different hints
+// or struct tag time formats get correct encoders
 type cacheKey struct {
-	typ   reflect.Type
-	hints hint
+	typ            reflect.Type
+	hints          hint
+	timeFormat     string
+	durationFormat string
+}
+
+func (o *Options) cacheKey(typ reflect.Type, hints hint) cacheKey {
+	if o == nil {
+		return cacheKey{typ: typ, hints: hints}
+	}
+	return cacheKey{typ, hints, o.TimeFormat, o.DurationFormat}
 }
 
-func (c cache) codec(typ reflect.Type) *structCodec {
-	key := cacheKey{typ, 0}
+func (c cache) codec(typ reflect.Type, options *Options) *structCodec {
+	key := options.cacheKey(typ, 0)
 	if x := c[key]; x != nil {
 		if c, ok := x.(*structCodec); ok {
 			return c
@@ -94,7 +174,7 @@ func cacheType(typ reflect.Type) bool {
 	}
 }
 func (c cache) encoder(typ reflect.Type, options *Options, hints hint) (encoder, error) {
-	key := cacheKey{typ, hints}
+	key := options.cacheKey(typ, hints)
 	if x := c[key]; x != nil {
 		if e, ok := x.(encoder); ok {
 			return e, nil
@@ -109,7 +189,7 @@ func (c cache) encoder(typ reflect.Type, options *Options, hints hint) (encoder,
 }
 
 func (c cache) decoder(typ reflect.Type, options *Options) (decoder, error) {
-	key := cacheKey{typ, 0}
+	key := options.cacheKey(typ, 0)
 	if x := c[key]; x != nil {
 		if e, ok := x.(decoder); ok {
 			return e, nil
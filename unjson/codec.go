@@ -1,7 +1,9 @@
 package unjson
 
 import (
+	"fmt"
 	"reflect"
+	"strings"
 
 	"github.com/alxarch/njson"
 )
@@ -10,6 +12,10 @@ type structCodec struct {
 	fields    []codec
 	zeroValue reflect.Value
 	typ       reflect.Type
+	strict    bool // disallow unknown fields
+	check     bool // some fields are required or have defaults
+	fold      bool // match keys case insensitively
+	remain    *remainCodec
 }
 
 func (c *structCodec) Add(f codec) {
@@ -17,19 +23,44 @@ func (c *structCodec) Add(f codec) {
 }
 
 func (c *structCodec) Get(key string) *codec {
+	if i := c.index(key); 0 <= i && i < len(c.fields) {
+		return &c.fields[i]
+	}
+	return nil
+}
+
+func (c *structCodec) index(key string) int {
 	for i := range c.fields {
-		f := &c.fields[i]
-		if f.key == key {
-			return f
+		if c.fields[i].key == key {
+			return i
 		}
 	}
-	return nil
+	return -1
+}
+
+// match finds the field for an object key when decoding.
+// Like encoding/json an exact match is preferred when matching case insensitively.
+func (c *structCodec) match(key string) int {
+	i := c.index(key)
+	if i == -1 && c.fold {
+		for i := range c.fields {
+			if strings.EqualFold(c.fields[i].key, key) {
+				return i
+			}
+		}
+	}
+	return i
 }
 
 // codec is a field encoder/decoder
 type codec struct {
 	key   string
-	index []int // embedded struct index
+	name  string // Go field name
+	index []int  // embedded struct index
+	// required field must be present when decoding
+	required bool
+	// default value to decode when the field is not present
+	defaultValue njson.Node
 	decoder
 	encoder
 	omiter
@@ -51,6 +82,11 @@ func (c *structCodec) omit(v reflect.Value) bool {
 			return false
 		}
 	}
+	if c.remain != nil {
+		if f := fieldByIndex(v, c.remain.index); f.IsValid() && f.Len() != 0 {
+			return false
+		}
+	}
 	return true
 }
 
@@ -82,6 +118,13 @@ func (c *structCodec) encode(b []byte, v reflect.Value) ([]byte, error) {
 			return b, err
 		}
 	}
+	if c.remain != nil {
+		if fv = fieldByIndex(v, c.remain.index); fv.IsValid() {
+			if b, more, err = c.remain.encode(b, more, fv, c); err != nil {
+				return b, err
+			}
+		}
+	}
 	b = append(b, end[more:]...)
 	return b, nil
 }
@@ -103,6 +146,10 @@ func (c *structCodec) merge(typ reflect.Type, options *Options, index []int, cod
 		if tag == "-" {
 			continue
 		}
+		if hints&hintString == hintString && !isQuotable(field.Type) {
+			// Like encoding/json ignore the string option for non scalar types
+			hints &^= hintString
+		}
 		index = append(index[:depth], field.Index...)
 		if !tagged && field.Anonymous {
 			t := field.Type
@@ -119,20 +166,43 @@ func (c *structCodec) merge(typ reflect.Type, options *Options, index []int, cod
 				continue
 			}
 		}
+		if hints&hintRemain == hintRemain {
+			if c.remain != nil && cmpIndex(c.remain.index, index) != -1 {
+				continue
+			}
+			rc, err := newRemainCodec(field, index, options, codecs)
+			if err != nil {
+				return err
+			}
+			c.remain = rc
+			continue
+		}
 		// tag = string(strjson.Escape(nil, tag))
 		if ff := c.Get(tag); ff != nil && cmpIndex(ff.index, index) != -1 {
 			continue
 		}
 
-		dec, err := codecs.decoder(field.Type, options)
+		fieldOptions := options
+		if hints&hintTimeFormat != 0 {
+			if hasTimeFormat(field.Type) {
+				// Codecs are cached by format so the tag formats do not leak to other fields
+				fieldOptions = options.withFieldFormat(hints)
+			}
+			hints &^= hintTimeFormat
+		}
+		dec, err := codecs.decoder(field.Type, fieldOptions)
 		if err != nil {
 			return err
 		}
-		enc, err := codecs.encoder(field.Type, options, hints)
+		enc, err := codecs.encoder(field.Type, fieldOptions, hints)
 		if err != nil {
 			return err
 		}
 
+		if hints&hintString == hintString {
+			dec = newQuotedDecoder(dec)
+		}
+
 		var omit omiter
 		if hints&hintOmitempty == hintOmitempty {
 			if enc, ok := enc.(omiterer); ok {
@@ -144,34 +214,63 @@ func (c *structCodec) merge(typ reflect.Type, options *Options, index []int, cod
 			omit = omitNever{}
 		}
 
-		c.Add(codec{
+		fc := codec{
 			key:     tag,
+			name:    field.Name,
 			index:   copyIndex(index),
 			decoder: dec,
 			encoder: enc,
 			omiter:  omit,
-		})
+		}
+		if required, def, ok := options.parseFieldDefault(field); ok {
+			fc.required = required
+			if def != "" {
+				if fc.defaultValue, err = parseDefault(def, field.Type, dec); err != nil {
+					return err
+				}
+			}
+			c.check = true
+		}
+		c.Add(fc)
 	}
 	return nil
 }
 
+// parseDefault parses the JSON value of a default tag option and checks that it decodes to typ.
+func parseDefault(def string, typ reflect.Type, dec decoder) (njson.Node, error) {
+	d := njson.Document{}
+	n, tail, err := d.Parse(def)
+	if err == nil && strings.TrimSpace(tail) != "" {
+		err = fmt.Errorf("Invalid tail %q", tail)
+	}
+	if err == nil {
+		err = dec.decode(reflect.New(typ).Elem(), n)
+	}
+	if err != nil {
+		return njson.Node{}, fmt.Errorf("Invalid default value %q for type %s: %s", def, typ, err)
+	}
+	return n, nil
+}
+
 func newStructCodec(typ reflect.Type, options *Options, codecs cache) (*structCodec, error) {
 	if typ.Kind() == reflect.Ptr {
 		typ = typ.Elem()
 	}
 	if typ.Kind() != reflect.Struct {
-		return nil, errInvalidType
+		return nil, &njson.UnsupportedTypeError{Type: typ}
 	}
 
-	if c := codecs.codec(typ); c != nil {
+	if c := codecs.codec(typ, options); c != nil {
 		return c, nil
 	}
 	c := structCodec{
 		typ:       typ,
 		fields:    make([]codec, 0, typ.NumField()),
 		zeroValue: reflect.Zero(typ),
+		strict:    options.DisallowUnknownFields,
+		fold:      options.CaseInsensitive,
 	}
-	codecs[cacheKey{typ, 0}] = &c
+	codecs[options.cacheKey(typ, 0)] = &c
 	if err := c.merge(typ, options, nil, codecs); err != nil {
 		return nil, err
 	}
@@ -199,43 +298,129 @@ func (c *structCodec) decode(v reflect.Value, n njson.Node) (err error) {
 		return nil
 	case njson.TypeObject:
 		var (
-			field reflect.Value
-			fc    *codec
-			iter  = n.Values()
+			field   reflect.Value
+			fc      *codec
+			iter    = n.Values()
+			unknown []string
+			remain  reflect.Value
+			buf     [64]bool
+			seen    []bool
 		)
+		if c.check {
+			// Track fields present in the object
+			if seen = buf[:]; len(c.fields) > len(buf) {
+				seen = make([]bool, len(c.fields))
+			}
+			seen = seen[:len(c.fields)]
+		}
 		for iter.Next() {
-			fc = c.Get(iter.Key())
-			if fc == nil {
+			i := c.match(iter.Key())
+			if i == -1 && c.remain != nil {
+				if !remain.IsValid() {
+					if remain = fieldForDecode(v, c.remain.index); !remain.IsValid() {
+						continue
+					}
+					c.remain.reset(remain)
+				}
+				if err = c.remain.decode(remain, iter.Key(), iter.Value()); err != nil {
+					return wrapDecodeError(err, c.remain.typ.Elem(), c.remain.name, iter.Key(), -1)
+				}
 				continue
 			}
-			switch len(fc.index) {
-			case 1:
-				field = v.Field(fc.index[0])
-			case 0:
-				continue
-			default:
-				field = v.Field(fc.index[0])
-				for _, i := range fc.index[1:] {
-					if i == -1 {
-						if field.IsNil() {
-							field = reflect.New(field.Type().Elem())
-						}
-						field = field.Elem()
-					} else {
-						field = field.Field(i)
-					}
+			if i == -1 {
+				if c.strict {
+					unknown = append(unknown, iter.Key())
 				}
+				continue
+			}
+			if 0 <= i && i < len(seen) {
+				seen[i] = true
+			}
+			fc = &c.fields[i]
+			if field = fieldForDecode(v, fc.index); !field.IsValid() {
+				continue
 			}
 			if err = fc.decode(field, iter.Value()); err != nil {
-				return
+				return wrapDecodeError(err, field.Type(), fc.name, iter.Key(), -1)
+			}
+		}
+		if unknown != nil {
+			return &UnknownFieldsError{Keys: unknown}
+		}
+		if c.remain != nil && !remain.IsValid() {
+			// No unknown keys
+			if remain = fieldByIndex(v, c.remain.index); remain.IsValid() {
+				remain.Set(reflect.Zero(c.remain.typ))
+			}
+		}
+		var missing []string
+		for i := range seen {
+			if seen[i] {
+				continue
+			}
+			fc = &c.fields[i]
+			switch {
+			case fc.required:
+				missing = append(missing, fc.key)
+			case fc.defaultValue.Type() != njson.TypeInvalid:
+				if field = fieldForDecode(v, fc.index); !field.IsValid() {
+					continue
+				}
+				if err = fc.decode(field, fc.defaultValue); err != nil {
+					return wrapDecodeError(err, field.Type(), fc.name, fc.key, -1)
+				}
 			}
 		}
+		if missing != nil {
+			return &MissingFieldsError{Keys: missing}
+		}
 		return
 	default:
 		return n.TypeError(njson.TypeObject | njson.TypeNull)
 	}
 }
 
+// fieldForDecode returns the field of a struct value at index
+// allocating embedded struct pointers.
+func fieldForDecode(v reflect.Value, index []int) reflect.Value {
+	if len(index) == 0 {
+		return reflect.Value{}
+	}
+	v = v.Field(index[0])
+	for _, i := range index[1:] {
+		if i == -1 {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		} else {
+			v = v.Field(i)
+		}
+	}
+	return v
+}
+
+// isQuotable checks if a type can be encoded as a quoted string with the string tag option.
+func isQuotable(typ reflect.Type) bool {
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if ptr := reflect.PtrTo(typ); ptr.Implements(typAppender) ||
+		ptr.Implements(typJSONMarshaler) ||
+		ptr.Implements(typTextMarshaler) {
+		return false
+	}
+	switch typ.Kind() {
+	case reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	default:
+		return false
+	}
+}
+
 func copyIndex(a []int) (b []int) {
 	b = make([]int, len(a))
 	copy(b, a)
@@ -261,10 +446,18 @@ func cmpIndex(a, b []int) int {
 	return 1
 }
 
-type hint uint8
+type hint uint16
 
 const (
 	hintRaw hint = 1 << iota
 	hintHTML
 	hintOmitempty
+	hintString
+	hintRemain
+	hintUnix
+	hintUnixMillis
+	hintNanoseconds
+	hintText
 )
+
+const hintTimeFormat = hintUnix | hintUnixMillis | hintNanoseconds | hintText
@@ -4,8 +4,10 @@ import (
 	"encoding"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"reflect"
 	"strconv"
+	"strings"
 
 	"github.com/alxarch/njson/strjson"
 
@@ -39,11 +41,11 @@ type typeDecoder struct {
 // It handles the case of a x being a nil pointer by creating a new blank value.
 func (c *typeDecoder) Decode(x interface{}, n njson.Node) error {
 	if x == nil {
-		return errInvalidValueType
+		return ErrInvalidValueType
 	}
 	v := reflect.ValueOf(x)
 	if v.Type() != c.typ {
-		return errInvalidValueType
+		return ErrInvalidValueType
 	}
 	if v.IsNil() {
 		if n.Type() == njson.TypeNull {
@@ -51,14 +53,25 @@ func (c *typeDecoder) Decode(x interface{}, n njson.Node) error {
 		}
 		v.Set(reflect.New(c.typ.Elem()))
 	}
-	return c.decode(v.Elem(), n)
+	if err := c.decode(v.Elem(), n); err != nil {
+		e, ok := err.(*DecodeError)
+		if !ok {
+			e = &DecodeError{Type: c.typ.Elem(), Err: err}
+		}
+		e.setKeyPaths()
+		return e
+	}
+	return nil
 }
 
 var (
-	errInvalidValueType = errors.New("Invalid value type")
-	errValue            = errors.New("Unsupported value")
-	errInvalidType      = errors.New("Invalid type")
-	errNilNode          = errors.New("Nil JSON node")
+	// ErrInvalidValueType is the error for Go values not matching the type of a Decoder or Encoder.
+	ErrInvalidValueType = errors.New("Invalid value type")
+	// ErrUnsupportedValue is the error for Go values that cannot be encoded to JSON.
+	ErrUnsupportedValue = errors.New("Unsupported value")
+	// ErrNotPointer is the error for decoding to a non pointer type.
+	// Unlike njson.UnsupportedTypeError it signifies a misuse of the API by the caller.
+	ErrNotPointer = errors.New("Decode type is not a pointer")
 )
 
 // njsonDecoder implements the Decoder interface for types implementing njson.Unmarshaler
@@ -68,7 +81,7 @@ func (njsonDecoder) Decode(x interface{}, n njson.Node) error {
 	if x, ok := x.(njson.Unmarshaler); ok {
 		return x.UnmarshalNodeJSON(n)
 	}
-	return errInvalidValueType
+	return ErrInvalidValueType
 }
 
 func (njsonDecoder) decode(v reflect.Value, n njson.Node) error {
@@ -83,7 +96,7 @@ func (jsonDecoder) Decode(x interface{}, n njson.Node) (err error) {
 	if u, ok := x.(json.Unmarshaler); ok {
 		return n.WrapUnmarshalJSON(u)
 	}
-	return errInvalidValueType
+	return ErrInvalidValueType
 }
 
 func (jsonDecoder) decode(v reflect.Value, n njson.Node) (err error) {
@@ -91,7 +104,31 @@ func (jsonDecoder) decode(v reflect.Value, n njson.Node) (err error) {
 }
 
 // NewTypeDecoder creates a new decoder for a type.
+// The type must be a pointer or an empty interface type, otherwise it returns ErrNotPointer.
+// A nil type decodes to interface{} values.
+//
+// The following struct tag options are supported:
+//   - `required` to fail decoding if the key is missing from an object
+//   - `default=<JSON>` to decode a JSON value if the key is missing from an object.
+//     It must be the last tag option so that the value can contain commas.
+//   - `string` to decode numbers and booleans from quoted JSON strings.
+//     Unquoted values are also accepted.
+//   - `remain` (or `inline`) to collect object keys not matching any other field
+//     in a `map[string]T` field or as a raw JSON object in a `[]byte` field.
+//   - `unix` or `unixms` to decode `time.Time` values from Unix time numbers
+//     overriding `Options.TimeFormat`.
+//   - `ns` or `text` to decode `time.Duration` values from numbers of nanoseconds
+//     or from strings using `time.ParseDuration` overriding `Options.DurationFormat`.
+//
+// Unless a time format is set, `time.Time` values are decoded with their `UnmarshalJSON` method
+// and `time.Duration` values are decoded as integers.
+//
 func NewTypeDecoder(typ reflect.Type, tag string) (Decoder, error) {
+	options := Options{Tag: tag}
+	return newTypeDecoder(typ, &options)
+}
+
+func newTypeDecoder(typ reflect.Type, options *Options) (Decoder, error) {
 	switch {
 	case typ == nil:
 		return interfaceDecoder{}, nil
@@ -101,7 +138,11 @@ func NewTypeDecoder(typ reflect.Type, tag string) (Decoder, error) {
 		}
 		fallthrough
 	case typ.Kind() != reflect.Ptr:
-		return nil, errInvalidType
+		return nil, ErrNotPointer
+	case options.decodeFunc(typ.Elem()) != nil:
+		return &typeDecoder{typ: typ, decoder: options.decodeFunc(typ.Elem())}, nil
+	case typ.Elem() == typTime && options.formatTime():
+		return &typeDecoder{typ: typ, decoder: newTimeDecoder(options)}, nil
 	case typ.Implements(typNodeUnmarshaler):
 		return njsonDecoder{}, nil
 	case typ.Implements(typJSONUnmarshaler):
@@ -109,12 +150,11 @@ func NewTypeDecoder(typ reflect.Type, tag string) (Decoder, error) {
 	case typ.Implements(typTextUnmarshaler):
 		return textDecoder{}, nil
 	default:
-		if tag == "" {
-			tag = defaultTag
+		if options.Tag == "" {
+			options.Tag = defaultTag
 		}
-		options := Options{Tag: tag}
 		c := typeDecoder{typ: typ}
-		d, err := newDecoder(typ.Elem(), &options, cache{})
+		d, err := newDecoder(typ.Elem(), options, cache{})
 		if err != nil {
 			return nil, err
 		}
@@ -125,7 +165,24 @@ func NewTypeDecoder(typ reflect.Type, tag string) (Decoder, error) {
 
 func newDecoder(typ reflect.Type, options *Options, codecs cache) (decoder, error) {
 	if typ == nil {
-		return nil, errInvalidType
+		return nil, &njson.UnsupportedTypeError{Type: typ}
+	}
+	if fn := options.decodeFunc(typ); fn != nil {
+		return fn, nil
+	}
+	switch typ {
+	case typTime:
+		if options.formatTime() {
+			return newTimeDecoder(options), nil
+		}
+		// Use the UnmarshalJSON method of *time.Time
+		return addrDecoder{jsonDecoder{}}, nil
+	case typDuration:
+		if options.formatDuration() {
+			return durationDecoder{}, nil
+		}
+	case typTimePtr:
+		return newPtrDecoder(typ, options, codecs)
 	}
 	switch {
 	case typ.Implements(typNodeUnmarshaler):
@@ -151,7 +208,7 @@ func newDecoder(typ reflect.Type, options *Options, codecs cache) (decoder, erro
 		if typ.NumMethod() == 0 {
 			return interfaceDecoder{}, nil
 		}
-		return nil, errInvalidType
+		return nil, &njson.UnsupportedTypeError{Type: typ}
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
 		return intDecoder{}, nil
 	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
@@ -163,7 +220,7 @@ func newDecoder(typ reflect.Type, options *Options, codecs cache) (decoder, erro
 	case reflect.String:
 		return stringDecoder{}, nil
 	default:
-		return nil, errInvalidType
+		return nil, &njson.UnsupportedTypeError{Type: typ}
 	}
 }
 
@@ -182,7 +239,7 @@ func newArrayDecoder(typ reflect.Type, options *Options, codecs cache) (*arrayDe
 		size:      typ.Len(),
 		zeroValue: reflect.Zero(el),
 	}
-	codecs[cacheKey{typ, 0}] = &ad
+	codecs[options.cacheKey(typ, 0)] = &ad
 	dec, err := codecs.decoder(typ.Elem(), options)
 	if err != nil {
 		return nil, err
@@ -197,7 +254,10 @@ func (dec *arrayDecoder) decode(v reflect.Value, n njson.Node) error {
 		iter := n.Values()
 		for i := 0; i < dec.size; i++ {
 			if iter.Next() {
-				dec.decoder.decode(v.Index(i), iter.Value())
+				el := v.Index(i)
+				if err := dec.decoder.decode(el, iter.Value()); err != nil {
+					return wrapDecodeError(err, el.Type(), "", "", i)
+				}
 			} else {
 				for ; i < dec.size; i++ {
 					el := v.Index(i)
@@ -231,7 +291,7 @@ func newSliceDecoder(typ reflect.Type, options *Options, codecs cache) (*sliceDe
 	sd := sliceDecoder{
 		typ: typ,
 	}
-	codecs[cacheKey{typ, 0}] = &sd
+	codecs[options.cacheKey(typ, 0)] = &sd
 	dec, err := codecs.decoder(typ.Elem(), options)
 	if err != nil {
 		return nil, err
@@ -255,10 +315,10 @@ func (d *sliceDecoder) decode(v reflect.Value, n njson.Node) (err error) {
 		}
 		for iter.Next() {
 			i := iter.Index()
-			err = d.decoder.decode(v.Index(i), iter.Value())
-			if err != nil {
+			el := v.Index(i)
+			if err = d.decoder.decode(el, iter.Value()); err != nil {
 				v.SetLen(i)
-				break
+				return wrapDecodeError(err, el.Type(), "", "", i)
 			}
 		}
 	default:
@@ -289,10 +349,10 @@ func newMapDecoder(typ reflect.Type, options *Options, codecs cache) (*mapDecode
 	} else if key.Kind() == reflect.String {
 		md.keys = stringDecoder{}
 	} else {
-		return nil, errInvalidType
+		return nil, &njson.UnsupportedTypeError{Type: typ}
 	}
 	// First cache the decoder to avoid recursion issues
-	codecs[cacheKey{typ, 0}] = &md
+	codecs[options.cacheKey(typ, 0)] = &md
 	dec, err := codecs.decoder(el, options)
 	if err != nil {
 		return nil, err
@@ -311,7 +371,7 @@ func (d *mapDecoder) decode(v reflect.Value, n njson.Node) (err error) {
 			val.Set(d.zeroValue)
 			err = d.decoder.decode(val, iter.Value())
 			if err != nil {
-				return
+				return wrapDecodeError(err, val.Type(), "", iter.Key(), -1)
 			}
 			v.SetMapIndex(reflect.ValueOf(iter.Key()), val)
 		}
@@ -332,7 +392,7 @@ func newPtrDecoder(typ reflect.Type, options *Options, codecs cache) (*ptrDecode
 		typ:  typ.Elem(),
 		zero: reflect.Zero(typ),
 	}
-	codecs[cacheKey{typ, 0}] = &pd
+	codecs[options.cacheKey(typ, 0)] = &pd
 	dec, err := codecs.decoder(pd.typ, options)
 	if err != nil {
 		return nil, err
@@ -383,7 +443,7 @@ func (interfaceDecoder) Decode(x interface{}, n njson.Node) error {
 
 func (interfaceDecoder) decode(v reflect.Value, n njson.Node) error {
 	if !v.CanAddr() {
-		return errInvalidValueType
+		return ErrInvalidValueType
 	}
 	if x, ok := n.ToInterface(); ok {
 		xx := v.Addr().Interface().(*interface{})
@@ -474,6 +534,79 @@ func (floatDecoder) decode(v reflect.Value, n njson.Node) (err error) {
 	}
 }
 
+// newQuotedDecoder wraps the decoder of a field with the `string` tag option.
+// The decoder of a pointer field is copied to wrap the decoder of its element.
+func newQuotedDecoder(dec decoder) decoder {
+	switch d := dec.(type) {
+	case *ptrDecoder:
+		pd := *d
+		pd.decoder = newQuotedDecoder(d.decoder)
+		return &pd
+	case intDecoder:
+		return quotedIntDecoder{}
+	case uintDecoder:
+		return quotedUintDecoder{}
+	default:
+		return quotedDecoder{dec}
+	}
+}
+
+// quotedIntDecoder decodes quoted integers with strconv.
+// Integers are quoted to keep their precision which would be lost by parsing them as float64.
+type quotedIntDecoder struct{}
+
+func (quotedIntDecoder) decode(v reflect.Value, n njson.Node) error {
+	if n.Type() != njson.TypeString {
+		return intDecoder{}.decode(v, n)
+	}
+	s := n.Unescaped()
+	i, err := strconv.ParseInt(s, 10, v.Type().Bits())
+	if err != nil {
+		return fmt.Errorf("Invalid quoted value %q: %w", s, err)
+	}
+	v.SetInt(i)
+	return nil
+}
+
+// quotedUintDecoder decodes quoted unsigned integers with strconv.
+type quotedUintDecoder struct{}
+
+func (quotedUintDecoder) decode(v reflect.Value, n njson.Node) error {
+	if n.Type() != njson.TypeString {
+		return uintDecoder{}.decode(v, n)
+	}
+	s := n.Unescaped()
+	u, err := strconv.ParseUint(s, 10, v.Type().Bits())
+	if err != nil {
+		return fmt.Errorf("Invalid quoted value %q: %w", s, err)
+	}
+	v.SetUint(u)
+	return nil
+}
+
+// quotedDecoder decodes values encoded as JSON strings for the `string` tag option.
+// Values that are not strings are decoded as is.
+type quotedDecoder struct {
+	decoder decoder
+}
+
+func (d quotedDecoder) decode(v reflect.Value, n njson.Node) error {
+	if n.Type() != njson.TypeString {
+		return d.decoder.decode(v, n)
+	}
+	s := n.Unescaped()
+	doc := njson.Blank()
+	defer doc.Close()
+	q, tail, err := doc.Parse(s)
+	if err == nil && strings.TrimSpace(tail) != "" {
+		err = fmt.Errorf("Invalid tail %q", tail)
+	}
+	if err != nil {
+		return fmt.Errorf("Invalid quoted value %q: %w", s, err)
+	}
+	return d.decoder.decode(v, q)
+}
+
 type textDecoder struct{}
 
 func (textDecoder) decode(v reflect.Value, n njson.Node) error {
@@ -487,5 +620,5 @@ func (textDecoder) Decode(x interface{}, n njson.Node) error {
 	if u, ok := x.(encoding.TextUnmarshaler); ok {
 		return n.WrapUnmarshalText(u)
 	}
-	return errInvalidValueType
+	return ErrInvalidValueType
 }
@@ -1,10 +1,12 @@
 package unjson
 
 import (
+	"bytes"
 	"encoding"
 	"encoding/json"
 	"math"
 	"reflect"
+	"sort"
 	"strconv"
 
 	"github.com/alxarch/njson"
@@ -38,14 +40,14 @@ var (
 
 func (m *typeEncoder) Encode(out []byte, x interface{}) ([]byte, error) {
 	if x == nil {
-		return out, errInvalidValueType
+		return out, ErrInvalidValueType
 	}
 	v := reflect.ValueOf(x)
 	if v.Kind() == reflect.Ptr {
 		v = v.Elem()
 	}
 	if v.Type() != m.typ {
-		return out, errInvalidValueType
+		return out, ErrInvalidValueType
 	}
 	return m.encode(out, v)
 }
@@ -62,6 +64,15 @@ func (m *typeEncoder) Encode(out []byte, x interface{}) ([]byte, error) {
 //     by the `options.OmitMethod`. To omit empty values by default use `options.OmitEmpty`.
 //   - `8bit` mark a string value as `8bit` to avoid costly JSON unescaping
 //   - `html` mark a string value as HTML text that should be escaped
+//   - `string` encode a number or boolean value as a quoted JSON string
+//   - `remain` (or `inline`) mark a `map[string]T` or `[]byte` field that holds
+//     object keys not matching any other field. The keys are written after the other fields.
+//   - `unix` or `unixms` encode a `time.Time` value as Unix time overriding `options.TimeFormat`
+//   - `ns` or `text` encode a `time.Duration` value as nanoseconds or as a string
+//     overriding `options.DurationFormat`
+//
+// Unless a time format is set, `time.Time` values are encoded with their `MarshalJSON` method
+// and `time.Duration` values are encoded as integers.
 //
 func NewTypeEncoder(typ reflect.Type, options Options) (Encoder, error) {
 	options = options.normalize()
@@ -70,7 +81,7 @@ func NewTypeEncoder(typ reflect.Type, options Options) (Encoder, error) {
 
 func newTypeEncoder(typ reflect.Type, options *Options) (*typeEncoder, error) {
 	if typ == nil {
-		return nil, errInvalidType
+		return nil, &njson.UnsupportedTypeError{Type: typ}
 	}
 	m := typeEncoder{}
 	if typ.Kind() == reflect.Ptr {
@@ -80,6 +91,12 @@ func newTypeEncoder(typ reflect.Type, options *Options) (*typeEncoder, error) {
 		typ = reflect.PtrTo(typ)
 	}
 	switch {
+	case options.encodeFunc(m.typ) != nil:
+		m.encoder = options.encodeFunc(m.typ)
+	case m.typ == typTime && options.formatTime():
+		m.encoder = newTimeEncoder(options)
+	case m.typ == typDuration && options.formatDuration():
+		m.encoder = newDurationEncoder(options)
 	case typ.Implements(typAppender):
 		m.encoder = njsonEncoder{}
 	case typ.Implements(typJSONMarshaler):
@@ -98,7 +115,19 @@ func newTypeEncoder(typ reflect.Type, options *Options) (*typeEncoder, error) {
 
 func newEncoder(typ reflect.Type, options *Options, hints hint, codecs cache) (encoder, error) {
 	if typ == nil {
-		return nil, errInvalidType
+		return nil, &njson.UnsupportedTypeError{Type: typ}
+	}
+	if fn := options.encodeFunc(typ); fn != nil {
+		return fn, nil
+	}
+	switch {
+	case typ == typTime && options.formatTime():
+		return newTimeEncoder(options), nil
+	case typ == typDuration && options.formatDuration():
+		return newDurationEncoder(options), nil
+	case typ == typTimePtr:
+		// Handle nil pointers before calling time.Time methods
+		return newPtrEncoder(typ, options, hints, codecs)
 	}
 	switch {
 	case typ.Implements(typAppender):
@@ -121,23 +150,23 @@ func newEncoder(typ reflect.Type, options *Options, hints hint, codecs cache) (e
 		return newMapEncoder(typ, options, codecs)
 	case reflect.Interface:
 		if typ.NumMethod() == 0 {
-			return interfaceEncoder{}, nil
+			return interfaceEncoder{cache: options.dynamicCache()}, nil
 		}
-		return nil, errInvalidType
+		return nil, &njson.UnsupportedTypeError{Type: typ}
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-		return intEncoder{}, nil
+		return newQuotedEncoder(intEncoder{}, hints), nil
 	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-		return uintEncoder{}, nil
+		return newQuotedEncoder(uintEncoder{}, hints), nil
 	case reflect.Float32:
-		return newFloatEncoder(32, options)
+		return newQuotedEncoder(newFloatEncoder(32, options), hints), nil
 	case reflect.Float64:
-		return newFloatEncoder(32, options)
+		return newQuotedEncoder(newFloatEncoder(64, options), hints), nil
 	case reflect.Bool:
-		return boolEncoder{}, nil
+		return newQuotedEncoder(boolEncoder{}, hints), nil
 	case reflect.String:
 		return newStringEncoder(hints), nil
 	default:
-		return nil, errInvalidType
+		return nil, &njson.UnsupportedTypeError{Type: typ}
 	}
 }
 
@@ -187,7 +216,7 @@ func (HTML stringEncoder) encode(b []byte, v reflect.Value) ([]byte, error) {
 }
 
 type interfaceEncoder struct {
-	// options *Options
+	cache *Cache // encodes dynamic values with the same options
 }
 
 func (c interfaceEncoder) Encode(out []byte, x interface{}) ([]byte, error) {
@@ -201,7 +230,12 @@ func (c interfaceEncoder) encode(b []byte, v reflect.Value) ([]byte, error) {
 	if v.IsNil() {
 		return append(b, strNull...), nil
 	}
-	return AppendJSON(b, v.Interface())
+	x := v.Interface()
+	enc, err := c.cache.Encoder(reflect.TypeOf(x))
+	if err != nil {
+		return b, err
+	}
+	return enc.Encode(b, x)
 }
 
 type textEncoder struct{}
@@ -220,13 +254,15 @@ type mapEncoder struct {
 	typ     reflect.Type
 	encoder encoder
 	keys    encoder
+	sorted  bool
 }
 
 func newMapEncoder(typ reflect.Type, options *Options, codecs cache) (*mapEncoder, error) {
 	key := typ.Key()
 	el := typ.Elem()
 	me := mapEncoder{
-		typ: typ,
+		typ:    typ,
+		sorted: options.SortMapKeys,
 	}
 
 	if key.Implements(typTextMarshaler) {
@@ -234,9 +270,9 @@ func newMapEncoder(typ reflect.Type, options *Options, codecs cache) (*mapEncode
 	} else if key.Kind() == reflect.String {
 		me.keys = stringEncoder(false)
 	} else {
-		return nil, errInvalidType
+		return nil, &njson.UnsupportedTypeError{Type: typ}
 	}
-	codecs[cacheKey{typ, 0}] = &me
+	codecs[options.cacheKey(typ, 0)] = &me
 	enc, err := codecs.encoder(el, options, 0)
 	if err != nil {
 		return nil, err
@@ -249,6 +285,9 @@ func (d *mapEncoder) encode(out []byte, v reflect.Value) ([]byte, error) {
 	if v.IsNil() {
 		return append(out, strNull...), nil
 	}
+	if d.sorted {
+		return d.encodeSorted(out, v)
+	}
 	out = append(out, delimBeginObject)
 	var err error
 	for i, key := range v.MapKeys() {
@@ -269,13 +308,59 @@ func (d *mapEncoder) encode(out []byte, v reflect.Value) ([]byte, error) {
 	return out, nil
 }
 
+func (d *mapEncoder) encodeSorted(out []byte, v reflect.Value) ([]byte, error) {
+	buf, keys, err := sortMapKeys(d.keys, v)
+	if err != nil {
+		return out, err
+	}
+	out = append(out, delimBeginObject)
+	for i := range keys {
+		k := &keys[i]
+		if i > 0 {
+			out = append(out, delimValueSeparator)
+		}
+		out = append(out, buf[k.start:k.end]...)
+		out = append(out, delimNameSeparator)
+		out, err = d.encoder.encode(out, v.MapIndex(k.key))
+		if err != nil {
+			return out, err
+		}
+	}
+	out = append(out, delimEndObject)
+	return out, nil
+}
+
+// mapKey is a map key encoded in a buffer at [start:end]
+type mapKey struct {
+	start, end int
+	key        reflect.Value
+}
+
+// sortMapKeys encodes the keys of a map to a buffer and sorts them by their string value.
+func sortMapKeys(keys encoder, v reflect.Value) (buf []byte, sorted []mapKey, err error) {
+	sorted = make([]mapKey, v.Len())
+	for i, key := range v.MapKeys() {
+		start := len(buf)
+		if buf, err = keys.encode(buf, key); err != nil {
+			return nil, nil, err
+		}
+		sorted[i] = mapKey{start, len(buf), key}
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		a, b := &sorted[i], &sorted[j]
+		// Compare without quotes
+		return bytes.Compare(buf[a.start+1:a.end-1], buf[b.start+1:b.end-1]) == -1
+	})
+	return
+}
+
 type ptrEncoder struct {
 	encoder encoder
 }
 
 func newPtrEncoder(typ reflect.Type, options *Options, hints hint, codecs cache) (*ptrEncoder, error) {
 	pe := new(ptrEncoder)
-	codecs[cacheKey{typ, hints}] = pe
+	codecs[options.cacheKey(typ, hints)] = pe
 	enc, err := codecs.encoder(typ.Elem(), options, hints)
 	if err != nil {
 		return nil, err
@@ -297,7 +382,7 @@ type sliceEncoder struct {
 
 func newSliceEncoder(typ reflect.Type, options *Options, hints hint, codecs cache) (*sliceEncoder, error) {
 	se := new(sliceEncoder)
-	codecs[cacheKey{typ, hints}] = se
+	codecs[options.cacheKey(typ, hints)] = se
 	enc, err := codecs.encoder(typ.Elem(), options, hints)
 	if err != nil {
 		return nil, err
@@ -336,7 +421,7 @@ type arrayEncoder struct {
 func newArrayEncoder(typ reflect.Type, options *Options, hints hint, codecs cache) (*arrayEncoder, error) {
 	ae := new(arrayEncoder)
 	ae.size = typ.Len()
-	codecs[cacheKey{typ, hints}] = ae
+	codecs[options.cacheKey(typ, hints)] = ae
 	enc, err := codecs.encoder(typ.Elem(), options, hints)
 	if err != nil {
 		return nil, err
@@ -391,9 +476,9 @@ type floatEncoder struct {
 	allowNan bool
 }
 
-func newFloatEncoder(bits int, options *Options) (*floatEncoder, error) {
+func newFloatEncoder(bits int, options *Options) *floatEncoder {
 	e := floatEncoder{bits, options.AllowInf, options.AllowNaN}
-	return &e, nil
+	return &e
 }
 
 func (e *floatEncoder) encode(out []byte, v reflect.Value) ([]byte, error) {
@@ -405,18 +490,40 @@ func (e *floatEncoder) encode(out []byte, v reflect.Value) ([]byte, error) {
 			}
 			return append(out, "+Inf"...), nil
 		}
-		return out, errValue
+		return out, ErrUnsupportedValue
 	}
 	if math.IsNaN(f) {
 		if e.allowNan {
 			return append(out, "NaN"...), nil
 		}
-		return out, errValue
+		return out, ErrUnsupportedValue
 	}
 	out = numjson.AppendFloat(out, f, e.bits)
 	return out, nil
 }
 
+// quotedEncoder wraps the output of an encoder in quotes for the `string` tag option
+type quotedEncoder struct {
+	encoder encoder
+}
+
+func newQuotedEncoder(enc encoder, hints hint) encoder {
+	if hints&hintString == hintString {
+		return quotedEncoder{enc}
+	}
+	return enc
+}
+
+func (e quotedEncoder) encode(out []byte, v reflect.Value) ([]byte, error) {
+	out = append(out, delimString)
+	out, err := e.encoder.encode(out, v)
+	if err != nil {
+		return out, err
+	}
+	out = append(out, delimString)
+	return out, nil
+}
+
 const (
 	delimString         = '"'
 	delimBeginObject    = '{'
@@ -0,0 +1,139 @@
+package unjson
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// DecodeError is the error for JSON values that failed to decode to a Go value.
+type DecodeError struct {
+	Type  reflect.Type // Go type of the value that failed to decode
+	Field string       // Go struct field path of the value if any (ie `Orders.Customer.ID`)
+	Err   error        // The underlying error
+	path  []pathSegment
+}
+
+// pathSegment is an object key or an array index of a JSON path
+type pathSegment struct {
+	key   string
+	index int
+}
+
+// Path returns the JSON path of the value that failed to decode (ie `$.orders[2].customer.id`)
+func (e *DecodeError) Path() string {
+	return string(e.appendPath(nil))
+}
+
+func (e *DecodeError) appendPath(b []byte) []byte {
+	b = append(b, '$')
+	// Segments are added in reverse order while the error propagates
+	for i := len(e.path) - 1; 0 <= i && i < len(e.path); i-- {
+		seg := &e.path[i]
+		if seg.index >= 0 {
+			b = append(b, '[')
+			b = strconv.AppendInt(b, int64(seg.index), 10)
+			b = append(b, ']')
+		} else {
+			b = appendPathKey(b, seg.key)
+		}
+	}
+	return b
+}
+
+// appendPathKey appends an object key segment to a JSON path.
+func appendPathKey(b []byte, key string) []byte {
+	if isIdentifier(key) {
+		b = append(b, '.')
+		return append(b, key...)
+	}
+	b = append(b, '[', delimString)
+	b = append(b, key...)
+	return append(b, delimString, ']')
+}
+
+// setKeyPaths sets the paths of unknown keys once the path of their object is known.
+func (e *DecodeError) setKeyPaths() {
+	if u, ok := e.Err.(*UnknownFieldsError); ok {
+		path := e.appendPath(nil)
+		u.Paths = make([]string, len(u.Keys))
+		for i, key := range u.Keys {
+			u.Paths[i] = string(appendPathKey(path, key))
+		}
+	}
+}
+
+func (e *DecodeError) Error() string {
+	w := strings.Builder{}
+	w.WriteString(e.Err.Error())
+	w.WriteString(" at ")
+	w.WriteString(e.Path())
+	if e.Field != "" {
+		w.WriteString(" decoding field ")
+		w.WriteString(e.Field)
+	}
+	if e.Type != nil {
+		w.WriteString(" of type ")
+		w.WriteString(e.Type.String())
+	}
+	return w.String()
+}
+
+// Unwrap returns the underlying error
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// UnknownFieldsError is the error for object keys with no matching struct field
+// when decoding with Options.DisallowUnknownFields.
+type UnknownFieldsError struct {
+	Keys  []string
+	Paths []string // JSON path of each key (ie `$.items[1].nmae`)
+}
+
+func (e *UnknownFieldsError) Error() string {
+	return fmt.Sprintf("Unknown fields %q", e.Keys)
+}
+
+// MissingFieldsError is the error for required fields missing from an object.
+type MissingFieldsError struct {
+	Keys []string
+}
+
+func (e *MissingFieldsError) Error() string {
+	return fmt.Sprintf("Missing required fields %q", e.Keys)
+}
+
+// wrapDecodeError adds a path segment to a decode error.
+// Use index -1 for object keys.
+func wrapDecodeError(err error, typ reflect.Type, field, key string, index int) error {
+	e, ok := err.(*DecodeError)
+	if !ok {
+		e = &DecodeError{Type: typ, Err: err}
+	}
+	e.path = append(e.path, pathSegment{key, index})
+	if field != "" {
+		if e.Field == "" {
+			e.Field = field
+		} else {
+			e.Field = field + "." + e.Field
+		}
+	}
+	return e
+}
+
+func isIdentifier(key string) bool {
+	if key == "" {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		switch c := key[i]; {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', c == '_', c == '$':
+		case '0' <= c && c <= '9' && i > 0:
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -1,7 +1,11 @@
 package unjson
 
 import (
+	"reflect"
+	"strings"
 	"testing"
+
+	"github.com/alxarch/njson"
 )
 
 func TestMarshal(t *testing.T) {
@@ -29,6 +33,42 @@ func TestMarshalBasicTypes(t *testing.T) {
 	assertEqual(t, string(data), `{"String":"foo","Uint":1,"Int":-1,"Bool":true,"Float":0.02,"Null":null,"Map":{"foo":"bar"},"Slice":[1,2,3]}`)
 }
 
+func TestMarshalQuoted(t *testing.T) {
+	id := int64(9007199254740993)
+	v := struct {
+		ID     *int64  `json:"id,string"`
+		Amount float32 `json:"amount,string"`
+		Count  uint    `json:"count,string,omitempty"`
+		OK     bool    `json:"ok,string"`
+		Name   string  `json:"name,string"`
+		Codes  []int   `json:"codes,string"`
+		Next   *int64  `json:"next,string"`
+	}{&id, 4.5, 0, true, "foo", []int{1, 2}, nil}
+	data, err := AppendJSON(nil, v)
+	assertNoError(t, err)
+	assertEqual(t, string(data), `{"id":"9007199254740993","amount":"4.5","ok":"true","name":"foo","codes":[1,2],"next":null}`)
+}
+
+func TestMarshalRemain(t *testing.T) {
+	v := struct {
+		ID    int               `json:"id"`
+		Extra map[string]string `json:",remain"`
+	}{1, map[string]string{"id": "foo", "name": "bar"}}
+	data, err := AppendJSON(nil, v)
+	assertNoError(t, err)
+	assertEqual(t, string(data), `{"id":1,"name":"bar"}`)
+	raw := struct {
+		ID    int    `json:"id"`
+		Extra []byte `json:",remain"`
+	}{1, []byte(` { "id": 2, "name": "bar" } `)}
+	data, err = AppendJSON(nil, raw)
+	assertNoError(t, err)
+	assertEqual(t, string(data), `{"id":1,"name":"bar"}`)
+	raw.Extra = []byte(`[]`)
+	_, err = AppendJSON(nil, raw)
+	assert(t, err != nil, "Expected invalid remain value error")
+}
+
 func TestMarshalPtr(t *testing.T) {
 	v := struct {
 		Foo string `json:"foo"`
@@ -72,3 +112,63 @@ func TestMarshalInterface(t *testing.T) {
 		t.Fatalf("Invalid data %s", data)
 	}
 }
+
+type upperKey string
+
+func (k upperKey) MarshalText() ([]byte, error) {
+	return []byte(strings.ToUpper(string(k))), nil
+}
+
+func TestMarshalSortMapKeys(t *testing.T) {
+	type Response struct {
+		ID    int                    `json:"id"`
+		Codes map[upperKey]int       `json:"codes"`
+		Data  map[string]interface{} `json:"data"`
+		Extra map[string]int         `json:",remain"`
+	}
+	r := Response{
+		ID:    1,
+		Codes: map[upperKey]int{"b": 2, "a": 1, "c": 3},
+		Data: map[string]interface{}{
+			"z": map[string]int{"y": 1, "x": 2},
+			"a": []interface{}{map[string]string{"b": "", "a": ""}},
+			"m": nil,
+		},
+		Extra: map[string]int{"zz": 1, "aa": 2, "id": 3, "a\"b": 4},
+	}
+	c := Cache{Options: Options{SortMapKeys: true}}
+	enc, err := c.Encoder(reflect.TypeOf(r))
+	assertNoError(t, err)
+	want := `{"id":1,"codes":{"A":1,"B":2,"C":3},"data":{"a":[{"a":"","b":""}],"m":null,"z":{"x":2,"y":1}},"a\"b":4,"aa":2,"zz":1}`
+	for i := 0; i < 10; i++ {
+		data, err := enc.Encode(nil, &r)
+		assertNoError(t, err)
+		assertEqual(t, string(data), want)
+	}
+	d := njson.Document{}
+	for i := 0; i < 10; i++ {
+		n, err := c.ToNode(&d, &r)
+		assertNoError(t, err)
+		data, err := n.AppendJSON(nil)
+		assertNoError(t, err)
+		assertEqual(t, string(data), want)
+	}
+}
+
+func TestMarshalInterfaceOptions(t *testing.T) {
+	type Item struct {
+		ItemName string
+		Count    int
+	}
+	// Dynamic values use the options of the encoder
+	c := Cache{Options: Options{FieldCase: "snake", OmitEmpty: true, SortMapKeys: true}}
+	x := map[string]interface{}{
+		"b": Item{ItemName: "foo"},
+		"a": map[string]int{"y": 1, "x": 2},
+	}
+	enc, err := c.Encoder(reflect.TypeOf(x))
+	assertNoError(t, err)
+	data, err := enc.Encode(nil, x)
+	assertNoError(t, err)
+	assertEqual(t, string(data), `{"a":{"x":2,"y":1},"b":{"item_name":"foo"}}`)
+}
@@ -0,0 +1,227 @@
+package unjson
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/alxarch/njson"
+	"github.com/alxarch/njson/numjson"
+	"github.com/alxarch/njson/strjson"
+)
+
+// ToNode adds a new node to a document converting a Go value.
+//
+// It uses the package-wide cache of `Encoder` instances so struct fields
+// are named and omitted following the same tag rules as `Marshal`.
+// Values that implement `json.Marshaler` or `njson.Appender` are
+// encoded to JSON and parsed into the document.
+func ToNode(d *njson.Document, x interface{}) (njson.Node, error) {
+	return defaultCache.ToNode(d, x)
+}
+
+// ToNode adds a new node to a document converting a Go value using cache.Options
+func (c *Cache) ToNode(d *njson.Document, x interface{}) (njson.Node, error) {
+	if d == nil {
+		return njson.Node{}, ErrInvalidValueType
+	}
+	if x == nil {
+		return d.Null(), nil
+	}
+	enc, err := c.Encoder(reflect.TypeOf(x))
+	if err != nil {
+		return njson.Node{}, err
+	}
+	v := reflect.ValueOf(x)
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return d.Null(), nil
+		}
+		v = v.Elem()
+	}
+	if e, ok := enc.(*typeEncoder); ok {
+		return toNode(d, e.encoder, v)
+	}
+	return toNode(d, enc, v)
+}
+
+// toNode mirrors the encode method of each encoder type to build nodes instead of JSON text.
+func toNode(d *njson.Document, enc encoder, v reflect.Value) (njson.Node, error) {
+	switch e := enc.(type) {
+	case *structCodec:
+		obj := d.Object()
+		for i := range e.fields {
+			fc := &e.fields[i]
+			fv := fieldByIndex(v, fc.index)
+			if !fv.IsValid() || fc.omit(fv) {
+				continue
+			}
+			n, err := toNode(d, fc.encoder, fv)
+			if err != nil {
+				return njson.Node{}, err
+			}
+			obj.Set(fc.key, n)
+		}
+		if e.remain != nil {
+			if rv := fieldByIndex(v, e.remain.index); rv.IsValid() {
+				if err := remainToNode(d, obj, e, rv); err != nil {
+					return njson.Node{}, err
+				}
+			}
+		}
+		return obj, nil
+	case *ptrEncoder:
+		if v.IsNil() {
+			return d.Null(), nil
+		}
+		return toNode(d, e.encoder, v.Elem())
+	case *sliceEncoder:
+		arr := d.Array()
+		if !v.IsNil() {
+			for i := 0; i < v.Len(); i++ {
+				n, err := toNode(d, e.encoder, v.Index(i))
+				if err != nil {
+					return njson.Node{}, err
+				}
+				arr.Append(n)
+			}
+		}
+		return arr, nil
+	case *arrayEncoder:
+		arr := d.Array()
+		for i := 0; i < e.size; i++ {
+			n, err := toNode(d, e.encoder, v.Index(i))
+			if err != nil {
+				return njson.Node{}, err
+			}
+			arr.Append(n)
+		}
+		return arr, nil
+	case *mapEncoder:
+		if v.IsNil() {
+			return d.Null(), nil
+		}
+		keys := v.MapKeys()
+		if e.sorted {
+			_, sorted, err := sortMapKeys(e.keys, v)
+			if err != nil {
+				return njson.Node{}, err
+			}
+			for i := range sorted {
+				keys[i] = sorted[i].key
+			}
+		}
+		obj := d.Object()
+		for _, key := range keys {
+			var k string
+			if _, ok := e.keys.(textEncoder); ok {
+				text, err := key.Interface().(encoding.TextMarshaler).MarshalText()
+				if err != nil {
+					return njson.Node{}, err
+				}
+				k = strjson.Escaped(string(text), false, false)
+			} else {
+				k = strjson.Escaped(key.String(), false, false)
+			}
+			n, err := toNode(d, e.encoder, v.MapIndex(key))
+			if err != nil {
+				return njson.Node{}, err
+			}
+			obj.Set(k, n)
+		}
+		return obj, nil
+	case interfaceEncoder:
+		if v.IsNil() {
+			return d.Null(), nil
+		}
+		return e.cache.ToNode(d, v.Interface())
+	case stringEncoder:
+		if e {
+			return d.TextHTML(v.String()), nil
+		}
+		return d.Text(v.String()), nil
+	case rawStringEncoder:
+		return d.TextRaw(v.String()), nil
+	case intEncoder:
+		n := d.Null()
+		n.SetInt(v.Int())
+		return n, nil
+	case uintEncoder:
+		n := d.Null()
+		n.SetUint(v.Uint())
+		return n, nil
+	case boolEncoder:
+		if v.Bool() {
+			return d.True(), nil
+		}
+		return d.False(), nil
+	case *floatEncoder:
+		// Use encode to apply NaN/Inf rules
+		if _, err := e.encode(nil, v); err != nil {
+			return njson.Node{}, err
+		}
+		return d.NumberRaw(numjson.FormatFloat(v.Float(), e.bits)), nil
+	case textEncoder:
+		text, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+		if err != nil {
+			return njson.Node{}, err
+		}
+		return d.Text(string(text)), nil
+	default:
+		// Fallback to encoding and parsing JSON for custom marshalers
+		data, err := enc.encode(nil, v)
+		if err != nil {
+			return njson.Node{}, err
+		}
+		n, tail, err := d.Parse(string(data))
+		if err == nil && strings.TrimSpace(tail) != "" {
+			err = fmt.Errorf("Invalid tail %q", tail)
+		}
+		return n, err
+	}
+}
+
+// remainToNode adds the keys of a remain field that do not match a struct field to obj.
+func remainToNode(d *njson.Document, obj njson.Node, sc *structCodec, v reflect.Value) error {
+	c := sc.remain
+	if c.decoder == nil {
+		// Parse the raw object using encode to check its value
+		data, more, err := c.encode(nil, 0, v, sc)
+		if err != nil || more == 0 {
+			return err
+		}
+		data = append(data, delimEndObject)
+		n, _, err := d.Parse(string(data))
+		if err != nil {
+			return err
+		}
+		for values := n.Values(); values.Next(); {
+			obj.Set(values.Key(), values.Value())
+		}
+		return nil
+	}
+	keys := v.MapKeys()
+	if c.sorted {
+		_, sorted, err := sortMapKeys(stringEncoder(false), v)
+		if err != nil {
+			return err
+		}
+		for i := range sorted {
+			keys[i] = sorted[i].key
+		}
+	}
+	for _, key := range keys {
+		k := key.String()
+		if sc.index(k) != -1 {
+			// Struct fields take precedence
+			continue
+		}
+		n, err := toNode(d, c.encoder, v.MapIndex(key))
+		if err != nil {
+			return err
+		}
+		obj.Set(strjson.Escaped(k, false, false), n)
+	}
+	return nil
+}
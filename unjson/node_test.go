@@ -0,0 +1,87 @@
+package unjson
+
+import (
+	"testing"
+
+	"github.com/alxarch/njson"
+)
+
+func TestToNode(t *testing.T) {
+	type Embedded struct {
+		ID int64 `json:"id"`
+	}
+	type foo struct {
+		Embedded
+		Name    string            `json:"name"`
+		HTML    string            `json:"html,html"`
+		Skip    string            `json:"-"`
+		Empty   string            `json:"empty,omitempty"`
+		Tags    []string          `json:"tags"`
+		Ptr     *float64          `json:"ptr"`
+		Any     interface{}       `json:"any"`
+		Map     map[string]uint   `json:"map"`
+		Custom  customJSON        `json:"custom"`
+		Text    customText        `json:"text"`
+		Array   [2]bool           `json:"array"`
+		Nested  map[string]string `json:"nested,omitempty"`
+		private int
+	}
+	v := foo{
+		Embedded: Embedded{42},
+		Name:     "foo\n",
+		HTML:     "<p>",
+		Skip:     "skip",
+		Tags:     []string{"a", "b"},
+		Any:      map[string]interface{}{"bar": 1.5},
+		Map:      map[string]uint{"baz": 1},
+		Text:     "text",
+		Array:    [2]bool{true, false},
+	}
+	d := njson.Document{}
+	n, err := ToNode(&d, &v)
+	assertNoError(t, err)
+	data, err := n.AppendJSON(nil)
+	assertNoError(t, err)
+	expect, err := Marshal(&v)
+	assertNoError(t, err)
+	assertEqual(t, string(data), string(expect))
+	assertEqual(t, string(data), `{"id":42,"name":"foo\n","html":"\u003cp\u003e","tags":["a","b"],"ptr":null,"any":{"bar":1.5},"map":{"baz":1},"custom":"custom","text":"text","array":[true,false]}`)
+
+	n, err = ToNode(&d, nil)
+	assertNoError(t, err)
+	assertEqual(t, n.Type(), njson.TypeNull)
+	_, err = ToNode(&d, make(chan int))
+	assert(t, err != nil, "Expected error for chan")
+
+	n, err = ToNode(&d, float32(0.1))
+	assertNoError(t, err)
+	assertEqual(t, n.Raw(), "0.1")
+
+	_, err = ToNode(&d, tailJSON{})
+	assert(t, err != nil, "Expected error for invalid tail")
+
+	type remain struct {
+		ID    int               `json:"id"`
+		Extra map[string]string `json:",remain"`
+	}
+	type rawRemain struct {
+		ID    int    `json:"id"`
+		Extra []byte `json:",remain"`
+	}
+	for _, x := range []interface{}{
+		remain{1, map[string]string{"id": "foo", "name": "bar"}},
+		rawRemain{1, []byte(`{"id":2,"name":"bar"}`)},
+	} {
+		n, err = ToNode(&d, x)
+		assertNoError(t, err)
+		data, err = n.AppendJSON(nil)
+		assertNoError(t, err)
+		assertEqual(t, string(data), `{"id":1,"name":"bar"}`)
+	}
+}
+
+type tailJSON struct{}
+
+func (tailJSON) MarshalJSON() ([]byte, error) {
+	return []byte(`1 2`), nil
+}
@@ -3,6 +3,8 @@ package unjson
 import (
 	"reflect"
 	"strings"
+
+	"github.com/iancoleman/strcase"
 )
 
 // Options holds options for an Encoder/Decoder
@@ -12,6 +14,16 @@ type Options struct {
 	OmitMethod string // Method name for checking if a value is empty defaults to 'Omit'
 	AllowNaN   bool   // Allow NaN values for numbers
 	AllowInf   bool   // Allow ±Inf values for numbers
+
+	DisallowUnknownFields bool   // Return an error when decoding object keys with no matching struct field
+	CaseInsensitive       bool   // Match object keys to struct fields case insensitively if there is no exact match
+	FieldCase             string // Case transform for untagged field names, one of `snake`, `lower`, `camel`, `Camel`
+	TimeFormat            string // Format for time.Time values, a time layout or one of TimeFormatUnix, TimeFormatUnixMillis
+	DurationFormat        string // Format for time.Duration values, one of DurationFormatNanoseconds, DurationFormatText
+	SortMapKeys           bool   // Encode map keys in sorted order for deterministic output
+
+	registry *registry // Custom codec functions registered on a Cache
+	cache    *Cache    // Cache that builds the codecs and holds the caches for dynamic values
 }
 
 func (o *Options) tagKey() string {
@@ -25,8 +37,7 @@ func (o *Options) parseField(f reflect.StructField) (name string, hints hint, ok
 	key := o.tagKey()
 	name, hints, ok = parseTag(f.Tag, key)
 	if name == "" {
-		// TODO: add Options.DefaultFieldCase
-		name = f.Name
+		name = transformFieldCase(o.FieldCase, f.Name)
 	}
 	if o.OmitEmpty {
 		hints |= hintOmitempty
@@ -34,6 +45,31 @@ func (o *Options) parseField(f reflect.StructField) (name string, hints hint, ok
 	return
 }
 
+// transformFieldCase transforms a field name using the same modes as the code generator.
+func transformFieldCase(mode, name string) string {
+	switch mode {
+	case "snake":
+		return strcase.ToSnake(name)
+	case "lower":
+		return strings.ToLower(name)
+	case "camel":
+		return strcase.ToLowerCamel(name)
+	case "Camel":
+		return strcase.ToCamel(name)
+	default:
+		return name
+	}
+}
+
+// parseFieldDefault parses the required and default tag options of a field.
+func (o *Options) parseFieldDefault(f reflect.StructField) (required bool, def string, ok bool) {
+	tag, _ := f.Tag.Lookup(o.tagKey())
+	if i := strings.IndexByte(tag, ','); 0 <= i && i < len(tag) {
+		return parseDefaultHints(tag[i:])
+	}
+	return
+}
+
 func (o Options) normalize() Options {
 	if o.Tag == "" {
 		o.Tag = defaultTag
@@ -76,6 +112,10 @@ func parseHints(tag string) (hints hint) {
 			hint = tag
 			tag = ""
 		}
+		if strings.HasPrefix(hint, hintDefaultPrefix) {
+			// The default value is always last and may contain commas
+			return
+		}
 		switch hint {
 		case "omitempty":
 			hints |= hintOmitempty
@@ -83,6 +123,41 @@ func parseHints(tag string) (hints hint) {
 			hints |= hintRaw
 		case "html":
 			hints |= hintHTML
+		case "string":
+			hints |= hintString
+		case "remain", "inline":
+			hints |= hintRemain
+		case TimeFormatUnix:
+			hints |= hintUnix
+		case TimeFormatUnixMillis:
+			hints |= hintUnixMillis
+		case DurationFormatNanoseconds:
+			hints |= hintNanoseconds
+		case DurationFormatText:
+			hints |= hintText
+		}
+	}
+	return
+}
+
+const hintDefaultPrefix = "default="
+
+// parseDefaultHints parses the required and default options of a tag.
+// The default option consumes the rest of the tag so that its JSON value may contain commas.
+func parseDefaultHints(tag string) (required bool, def string, ok bool) {
+	for len(tag) > 0 {
+		tag = tag[1:]
+		if strings.HasPrefix(tag, hintDefaultPrefix) {
+			return required, tag[len(hintDefaultPrefix):], true
+		}
+		hint := tag
+		if i := strings.IndexByte(tag, ','); 0 <= i && i < len(tag) {
+			hint, tag = tag[:i], tag[i:]
+		} else {
+			tag = ""
+		}
+		if hint == "required" {
+			required, ok = true, true
 		}
 	}
 	return
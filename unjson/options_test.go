@@ -12,3 +12,13 @@ func TestParseField(t *testing.T) {
 	assertEqual(t, name, "")
 	assertEqual(t, h, hintOmitempty)
 }
+
+func TestParseDefaultHints(t *testing.T) {
+	required, def, ok := parseDefaultHints(`,omitempty,required,default={"a":[1,2]}`)
+	assert(t, ok, "Parse OK")
+	assertEqual(t, required, true)
+	assertEqual(t, def, `{"a":[1,2]}`)
+	assertEqual(t, parseHints(`,omitempty,default=[1,"html"]`), hintOmitempty)
+	_, _, ok = parseDefaultHints(`,omitempty`)
+	assert(t, !ok, "Parse not OK")
+}
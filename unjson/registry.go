@@ -0,0 +1,112 @@
+package unjson
+
+import (
+	"reflect"
+
+	"github.com/alxarch/njson"
+)
+
+// DecodeFunc decodes a JSON node to a Go value of a specific type
+type DecodeFunc func(v reflect.Value, n njson.Node) error
+
+func (fn DecodeFunc) decode(v reflect.Value, n njson.Node) error {
+	return fn(v, n)
+}
+
+// EncodeFunc appends the JSON encoding of a Go value of a specific type
+type EncodeFunc func(out []byte, v reflect.Value) ([]byte, error)
+
+func (fn EncodeFunc) encode(out []byte, v reflect.Value) ([]byte, error) {
+	return fn(out, v)
+}
+
+// registry holds custom codec functions by type.
+// It is never modified after creation so it can be shared by concurrent codec builds.
+type registry struct {
+	decoders map[reflect.Type]DecodeFunc
+	encoders map[reflect.Type]EncodeFunc
+}
+
+func (r *registry) decodeFunc(typ reflect.Type) DecodeFunc {
+	if r != nil {
+		return r.decoders[typ]
+	}
+	return nil
+}
+
+func (r *registry) encodeFunc(typ reflect.Type) EncodeFunc {
+	if r != nil {
+		return r.encoders[typ]
+	}
+	return nil
+}
+
+func (o *Options) decodeFunc(typ reflect.Type) DecodeFunc {
+	if o != nil {
+		return o.registry.decodeFunc(typ)
+	}
+	return nil
+}
+
+func (o *Options) encodeFunc(typ reflect.Type) EncodeFunc {
+	if o != nil {
+		return o.registry.encodeFunc(typ)
+	}
+	return nil
+}
+
+func (r *registry) clone() *registry {
+	c := registry{
+		decoders: make(map[reflect.Type]DecodeFunc),
+		encoders: make(map[reflect.Type]EncodeFunc),
+	}
+	if r != nil {
+		for typ, fn := range r.decoders {
+			c.decoders[typ] = fn
+		}
+		for typ, fn := range r.encoders {
+			c.encoders[typ] = fn
+		}
+	}
+	return &c
+}
+
+// RegisterDecodeFunc registers a function to decode values of type typ.
+//
+// Registered functions take precedence over built-in decoders and unmarshaler methods
+// so they can be used for types that cannot be modified (ie `net.IP`).
+// Decoders already returned by the cache are not affected.
+func (c *Cache) RegisterDecodeFunc(typ reflect.Type, fn DecodeFunc) {
+	c.mu.Lock()
+	r := c.registry.clone()
+	if fn == nil {
+		delete(r.decoders, typ)
+	} else {
+		r.decoders[typ] = fn
+	}
+	c.registry = r
+	// Cached decoders might use a previous decoder for typ
+	c.decoders = nil
+	c.dynamic = nil
+	c.mu.Unlock()
+}
+
+// RegisterEncodeFunc registers a function to encode values of type typ.
+//
+// Registered functions take precedence over built-in encoders and marshaler methods
+// so they can be used for types that cannot be modified (ie `net.IP`).
+// Encoders already returned by the cache are not affected.
+func (c *Cache) RegisterEncodeFunc(typ reflect.Type, fn EncodeFunc) {
+	c.mu.Lock()
+	r := c.registry.clone()
+	if fn == nil {
+		delete(r.encoders, typ)
+	} else {
+		r.encoders[typ] = fn
+	}
+	c.registry = r
+	// Cached encoders might use a previous encoder for typ
+	c.encoders = nil
+	c.dynamic = nil
+	c.mu.Unlock()
+}
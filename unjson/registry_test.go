@@ -0,0 +1,124 @@
+package unjson
+
+import (
+	"net"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/alxarch/njson"
+)
+
+func TestCacheRegisterFunc(t *testing.T) {
+	type Host struct {
+		IP      net.IP         `json:"ip"`
+		Timeout time.Duration  `json:"timeout"`
+		Retry   *time.Duration `json:"retry,omitempty"`
+	}
+	c := Cache{}
+	c.RegisterEncodeFunc(reflect.TypeOf(time.Duration(0)), func(out []byte, v reflect.Value) ([]byte, error) {
+		return strconv.AppendQuote(out, time.Duration(v.Int()).String()), nil
+	})
+	c.RegisterDecodeFunc(reflect.TypeOf(time.Duration(0)), func(v reflect.Value, n njson.Node) error {
+		if n.Type() != njson.TypeString {
+			return n.TypeError(njson.TypeString)
+		}
+		d, err := time.ParseDuration(n.Unescaped())
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	})
+	// Registered functions take precedence over TextMarshaler methods
+	c.RegisterEncodeFunc(reflect.TypeOf(net.IP{}), func(out []byte, v reflect.Value) ([]byte, error) {
+		return strconv.AppendInt(out, int64(len(v.Bytes())), 10), nil
+	})
+
+	retry := time.Second
+	h := Host{net.IPv4(127, 0, 0, 1), time.Minute, &retry}
+	enc, err := c.Encoder(reflect.TypeOf(h))
+	assertNoError(t, err)
+	data, err := enc.Encode(nil, &h)
+	assertNoError(t, err)
+	assertEqual(t, string(data), `{"ip":16,"timeout":"1m0s","retry":"1s"}`)
+
+	dec, err := c.Decoder(reflect.TypeOf(&h))
+	assertNoError(t, err)
+	d := njson.Document{}
+	n, _, err := d.Parse(`{"timeout":"2s","retry":"1h"}`)
+	assertNoError(t, err)
+	v := Host{}
+	assertNoError(t, dec.Decode(&v, n))
+	retry = time.Hour
+	assertEqual(t, v, Host{nil, 2 * time.Second, &retry})
+	var dur time.Duration
+	dec, err = c.Decoder(reflect.TypeOf(&dur))
+	assertNoError(t, err)
+	assertNoError(t, dec.Decode(&dur, n.Get("timeout")))
+	assertEqual(t, dur, 2*time.Second)
+
+	// Registering invalidates cached encoders
+	c.RegisterEncodeFunc(reflect.TypeOf(net.IP{}), nil)
+	enc, err = c.Encoder(reflect.TypeOf(h))
+	assertNoError(t, err)
+	data, err = enc.Encode(nil, &h)
+	assertNoError(t, err)
+	assertEqual(t, string(data), `{"ip":"127.0.0.1","timeout":"1m0s","retry":"1h0m0s"}`)
+}
+
+func TestCacheRegisterConcurrent(t *testing.T) {
+	type Host struct {
+		IP net.IP `json:"ip"`
+	}
+	typ := reflect.TypeOf(Host{})
+	c := Cache{}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			c.RegisterEncodeFunc(reflect.TypeOf(net.IP{}), func(out []byte, v reflect.Value) ([]byte, error) {
+				return append(out, `"old"`...), nil
+			})
+		}
+		c.RegisterEncodeFunc(reflect.TypeOf(net.IP{}), func(out []byte, v reflect.Value) ([]byte, error) {
+			return append(out, `"new"`...), nil
+		})
+	}()
+	for i := 0; i < 100; i++ {
+		_, err := c.Encoder(typ)
+		assertNoError(t, err)
+	}
+	<-done
+	// Encoders built with a replaced registry must not stay in the cache
+	enc, err := c.Encoder(typ)
+	assertNoError(t, err)
+	data, err := enc.Encode(nil, &Host{})
+	assertNoError(t, err)
+	assertEqual(t, string(data), `{"ip":"new"}`)
+}
+
+func TestCacheRegisterDynamic(t *testing.T) {
+	type Host struct {
+		IP    net.IP      `json:"ip"`
+		Extra interface{} `json:"extra"`
+	}
+	typ := reflect.TypeOf(Host{})
+	c := Cache{}
+	h := Host{Extra: net.IPv4(127, 0, 0, 1)}
+	for i := 0; i < 10; i++ {
+		c.RegisterEncodeFunc(reflect.TypeOf(net.IP{}), func(out []byte, v reflect.Value) ([]byte, error) {
+			return append(out, `"ip"`...), nil
+		})
+		enc, err := c.Encoder(typ)
+		assertNoError(t, err)
+		data, err := enc.Encode(nil, &h)
+		assertNoError(t, err)
+		assertEqual(t, string(data), `{"ip":"ip","extra":"ip"}`)
+	}
+	// Caches for dynamic values are kept on c and dropped on registration
+	assertEqual(t, len(c.dynamic), 1)
+	c.RegisterEncodeFunc(reflect.TypeOf(net.IP{}), nil)
+	assertEqual(t, len(c.dynamic), 0)
+}
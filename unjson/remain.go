@@ -0,0 +1,158 @@
+package unjson
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+
+	"github.com/alxarch/njson"
+	"github.com/alxarch/njson/strjson"
+)
+
+// remainCodec stores object keys with no matching struct field in a `remain` field.
+//
+// A map field with string keys decodes each value with the decoder for the map elements.
+// A []byte field stores the raw JSON object of the unknown keys.
+type remainCodec struct {
+	name    string // Go field name
+	index   []int
+	typ     reflect.Type
+	decoder decoder // map element decoder, nil for raw fields
+	encoder encoder // map element encoder, nil for raw fields
+	sorted  bool    // sort map keys
+}
+
+func newRemainCodec(field reflect.StructField, index []int, options *Options, codecs cache) (*remainCodec, error) {
+	typ := field.Type
+	rc := remainCodec{
+		name:  field.Name,
+		index: copyIndex(index),
+		typ:   typ,
+	}
+	switch {
+	case typ.Kind() == reflect.Slice && typ.Elem().Kind() == reflect.Uint8:
+		return &rc, nil
+	case typ.Kind() == reflect.Map && typ.Key().Kind() == reflect.String:
+		dec, err := codecs.decoder(typ.Elem(), options)
+		if err != nil {
+			return nil, err
+		}
+		enc, err := codecs.encoder(typ.Elem(), options, 0)
+		if err != nil {
+			return nil, err
+		}
+		rc.decoder, rc.encoder = dec, enc
+		rc.sorted = options.SortMapKeys
+		return &rc, nil
+	default:
+		return nil, fmt.Errorf("Invalid type %s for remain field %s", typ, field.Name)
+	}
+}
+
+// reset prepares a remain field to receive the first unknown key of an object.
+func (c *remainCodec) reset(v reflect.Value) {
+	if c.decoder == nil {
+		// Do not reuse the backing array of the caller's slice
+		v.SetBytes(nil)
+	} else {
+		v.Set(reflect.MakeMap(c.typ))
+	}
+}
+
+// decode adds an unknown key to a remain field.
+func (c *remainCodec) decode(v reflect.Value, key string, n njson.Node) (err error) {
+	if c.decoder == nil {
+		b := v.Bytes()
+		if len(b) == 0 {
+			b = append(b, delimBeginObject)
+		} else {
+			// Replace closing brace
+			b[len(b)-1] = delimValueSeparator
+		}
+		b = append(b, delimString)
+		b = append(b, key...)
+		b = append(b, delimString, delimNameSeparator)
+		if b, err = n.AppendJSON(b); err != nil {
+			return err
+		}
+		b = append(b, delimEndObject)
+		v.SetBytes(b)
+		return nil
+	}
+	val := reflect.New(c.typ.Elem()).Elem()
+	if err = c.decoder.decode(val, n); err != nil {
+		return err
+	}
+	k := reflect.ValueOf(strjson.Unescaped(key)).Convert(c.typ.Key())
+	v.SetMapIndex(k, val)
+	return nil
+}
+
+// encode appends the keys of a remain field that do not match a struct field.
+func (c *remainCodec) encode(b []byte, more uint, v reflect.Value, sc *structCodec) ([]byte, uint, error) {
+	const start = `{,`
+	if c.decoder == nil {
+		raw := bytes.TrimSpace(v.Bytes())
+		if len(raw) == 0 {
+			return b, more, nil
+		}
+		doc := njson.Blank()
+		defer doc.Close()
+		n, tail, err := doc.Parse(string(raw))
+		if err != nil || tail != "" || n.Type() != njson.TypeObject {
+			return b, more, fmt.Errorf("Invalid remain field %s value %q", c.name, raw)
+		}
+		for values := n.Values(); values.Next(); {
+			if sc.index(values.Key()) != -1 {
+				// Struct fields take precedence
+				continue
+			}
+			b = append(b, start[more])
+			more = 1
+			b = append(b, delimString)
+			b = append(b, values.Key()...)
+			b = append(b, delimString, delimNameSeparator)
+			if b, err = values.Value().AppendJSON(b); err != nil {
+				return b, more, err
+			}
+		}
+		return b, more, nil
+	}
+	if c.sorted {
+		buf, keys, err := sortMapKeys(stringEncoder(false), v)
+		if err != nil {
+			return b, more, err
+		}
+		for i := range keys {
+			k := &keys[i]
+			if sc.index(k.key.String()) != -1 {
+				continue
+			}
+			b = append(b, start[more])
+			more = 1
+			b = append(b, buf[k.start:k.end]...)
+			b = append(b, delimNameSeparator)
+			if b, err = c.encoder.encode(b, v.MapIndex(k.key)); err != nil {
+				return b, more, err
+			}
+		}
+		return b, more, nil
+	}
+	var err error
+	for _, key := range v.MapKeys() {
+		k := key.String()
+		if sc.index(k) != -1 {
+			// Struct fields take precedence
+			continue
+		}
+		b = append(b, start[more])
+		more = 1
+		b = append(b, delimString)
+		b = strjson.AppendEscaped(b, k, false)
+		b = append(b, delimString, delimNameSeparator)
+		if b, err = c.encoder.encode(b, v.MapIndex(key)); err != nil {
+			return b, more, err
+		}
+	}
+	return b, more, nil
+}
@@ -3,7 +3,9 @@ package unjson
 import (
 	"bufio"
 	"errors"
+	"fmt"
 	"io"
+	"reflect"
 	"strings"
 
 	"github.com/alxarch/njson"
@@ -12,8 +14,10 @@ import (
 // LineDecoder decodes  from a newline delimited JSON stream. (http://ndjson.org/)
 type LineDecoder struct {
 	Decoder                // Use a specific type decoder
+	Cache   *Cache         // Use decoders with specific options if Decoder is nil
 	r       *bufio.Reader  // underlying reader
 	p       njson.Document // a local njson.Parser
+	buf     []byte         // line buffer
 }
 
 // NewLineDecoder creates a new LineDecoder
@@ -22,8 +26,8 @@ func NewLineDecoder(r io.Reader) *LineDecoder {
 		return nil
 	}
 	d := LineDecoder{}
-	if r, ok := r.(*bufio.Reader); ok {
-		d.r = r
+	if br, ok := r.(*bufio.Reader); ok {
+		d.r = br
 	} else {
 		d.r = bufio.NewReader(r)
 	}
@@ -32,27 +36,188 @@ func NewLineDecoder(r io.Reader) *LineDecoder {
 
 // Decode decodes the next JSON line in the stream to x
 func (d *LineDecoder) Decode(x interface{}) (err error) {
-	s := strings.Builder{}
+	d.buf = d.buf[:0]
 	for {
 		line, isPrefix, err := d.r.ReadLine()
 		if err != nil {
 			return err
 		}
-		s.Write(line)
+		d.buf = append(d.buf, line...)
 		if isPrefix {
 			continue
 		}
-		n, tail, err := d.p.Parse(s.String())
+		d.p.Reset()
+		n, tail, err := d.p.Parse(string(d.buf))
 		if err != nil {
 			return err
 		}
 		if strings.TrimSpace(tail) != "" {
 			return errors.New("Invalid line delimited JSON")
 		}
-		if d.Decoder == nil {
-			return UnmarshalFromNode(n, x)
+		if d.Decoder != nil {
+			return d.Decoder.Decode(x, n)
 		}
-		return d.Decoder.Decode(x, n)
+		if d.Cache != nil {
+			dec, err := d.Cache.Decoder(reflect.TypeOf(x))
+			if err != nil {
+				return err
+			}
+			return dec.Decode(x, n)
+		}
+		return UnmarshalFromNode(n, x)
+	}
+}
+
+// ArrayDecoder decodes the elements of a top-level JSON array one at a time.
+//
+// Only the current element is held in memory so arbitrarily large arrays can be decoded.
+// Input after the closing bracket of the array is ignored.
+type ArrayDecoder struct {
+	Decoder           // Decoder for pointers to the element type
+	r       io.Reader // underlying reader
+	s       string    // unparsed input
+	chunk   []byte    // read buffer
+	doc     njson.Document
+	state   arrayState
+	eof     bool  // underlying reader is drained
+	err     error // sticky read or parse error
+}
+
+type arrayState uint8
+
+const (
+	arrayStart arrayState = iota
+	arrayFirst
+	arrayNext
+	arrayValue
+	arrayEnd
+)
+
+// DefaultArrayChunkSize is the minimum size of reads from the input of an ArrayDecoder
+const DefaultArrayChunkSize = 32 * 1024
+
+// NewArrayDecoder creates a new ArrayDecoder for elements of type elemType
+// using the package-wide cache of decoders.
+func NewArrayDecoder(r io.Reader, elemType reflect.Type) (*ArrayDecoder, error) {
+	return defaultCache.NewArrayDecoder(r, elemType)
+}
+
+// NewArrayDecoder creates a new ArrayDecoder for elements of type elemType using cache.Options
+func (c *Cache) NewArrayDecoder(r io.Reader, elemType reflect.Type) (*ArrayDecoder, error) {
+	if r == nil {
+		return nil, errors.New("Nil reader")
+	}
+	if elemType == nil {
+		return nil, &njson.UnsupportedTypeError{Type: elemType}
+	}
+	dec, err := c.Decoder(reflect.PtrTo(elemType))
+	if err != nil {
+		return nil, err
+	}
+	d := ArrayDecoder{
+		Decoder: dec,
+		r:       r,
+	}
+	return &d, nil
+}
+
+// Next decodes the next element of the array to x.
+// It returns io.EOF after the last element.
+// Errors decoding an element to x do not stop the iteration.
+func (d *ArrayDecoder) Next(x interface{}) error {
+	if d.err != nil {
+		return d.err
+	}
+	n, err := d.next()
+	if err != nil {
+		d.err = err
+		return err
+	}
+	return d.Decoder.Decode(x, n)
+}
+
+func (d *ArrayDecoder) next() (njson.Node, error) {
+	for {
+		s := strings.TrimLeft(d.s, " \t\r\n")
+		if s == "" && d.state != arrayEnd {
+			d.s = s
+			if err := d.fill(); err != nil {
+				return njson.Node{}, err
+			}
+			continue
+		}
+		switch d.state {
+		case arrayStart:
+			if s[0] != delimBeginArray {
+				return njson.Node{}, fmt.Errorf("Invalid array start %q", s[0])
+			}
+			d.s, d.state = s[1:], arrayFirst
+		case arrayFirst:
+			if s[0] == delimEndArray {
+				d.s, d.state = s[1:], arrayEnd
+				continue
+			}
+			d.s, d.state = s, arrayValue
+		case arrayNext:
+			switch s[0] {
+			case delimValueSeparator:
+				d.s, d.state = s[1:], arrayValue
+			case delimEndArray:
+				d.s, d.state = s[1:], arrayEnd
+			default:
+				return njson.Node{}, fmt.Errorf("Invalid array delimiter %q", s[0])
+			}
+		case arrayValue:
+			d.s = s
+			d.doc.Reset()
+			n, tail, err := d.doc.Parse(s)
+			if _, partial := err.(njson.UnexpectedEOF); partial || (err == nil && strings.TrimSpace(tail) == "" && !d.eof) {
+				// Numbers at the end of the input might continue in the next chunk
+				if err := d.fill(); err != nil {
+					return njson.Node{}, err
+				}
+				continue
+			}
+			if err != nil {
+				return njson.Node{}, err
+			}
+			d.s, d.state = tail, arrayNext
+			return n, nil
+		default:
+			return njson.Node{}, io.EOF
+		}
+	}
+}
+
+// fill appends a chunk of input to the unparsed input.
+func (d *ArrayDecoder) fill() error {
+	if d.eof {
+		return io.ErrUnexpectedEOF
+	}
+	// Grow reads along with the pending input to avoid re-parsing large elements too often
+	size := len(d.s)
+	if size < DefaultArrayChunkSize {
+		size = DefaultArrayChunkSize
+	}
+	if cap(d.chunk) < size {
+		d.chunk = make([]byte, size)
+	}
+	n, err := d.r.Read(d.chunk[:size])
+	if n > 0 {
+		// Parsed nodes reference the input string so it cannot share memory with the read buffer
+		d.s += string(d.chunk[:n])
+	}
+	switch err {
+	case nil:
+		return nil
+	case io.EOF:
+		d.eof = true
+		if n == 0 {
+			return io.ErrUnexpectedEOF
+		}
+		return nil
+	default:
+		return err
 	}
 }
 
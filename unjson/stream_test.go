@@ -0,0 +1,107 @@
+package unjson
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestArrayDecoder(t *testing.T) {
+	type Item struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+	}
+	src := ` [ {"id":1,"name":"foo"}, {"id":2,"name":"bar"} ,{"id":3}] trailing`
+	for _, r := range []io.Reader{
+		strings.NewReader(src),
+		iotest.OneByteReader(strings.NewReader(src)),
+		iotest.DataErrReader(strings.NewReader(src)),
+	} {
+		d, err := NewArrayDecoder(r, reflect.TypeOf(Item{}))
+		assertNoError(t, err)
+		var items []Item
+		for {
+			item := Item{}
+			err := d.Next(&item)
+			if err == io.EOF {
+				break
+			}
+			assertNoError(t, err)
+			items = append(items, item)
+		}
+		assertEqual(t, items, []Item{{1, "foo"}, {2, "bar"}, {3, ""}})
+		assertEqual(t, d.Next(&Item{}), io.EOF)
+	}
+
+	// Numbers split across reads
+	d, err := NewArrayDecoder(iotest.OneByteReader(strings.NewReader("[12345,-6.5e2]")), reflect.TypeOf(0.0))
+	assertNoError(t, err)
+	var f float64
+	assertNoError(t, d.Next(&f))
+	assertEqual(t, f, 12345.0)
+	assertNoError(t, d.Next(&f))
+	assertEqual(t, f, -650.0)
+	assertEqual(t, d.Next(&f), io.EOF)
+
+	d, err = NewArrayDecoder(strings.NewReader("[]"), reflect.TypeOf(0))
+	assertNoError(t, err)
+	assertEqual(t, d.Next(new(int)), io.EOF)
+
+	// Decode errors do not stop the iteration
+	d, err = NewArrayDecoder(strings.NewReader(`[1,"foo",3]`), reflect.TypeOf(0))
+	assertNoError(t, err)
+	var n int
+	assertNoError(t, d.Next(&n))
+	var e *DecodeError
+	err = d.Next(&n)
+	assert(t, errors.As(err, &e), "Invalid error %v", err)
+	assertNoError(t, d.Next(&n))
+	assertEqual(t, n, 3)
+
+	for _, src := range []string{`{"id":1}`, `[1,2`, `[1 2]`, `[1,`, ``} {
+		d, err := NewArrayDecoder(strings.NewReader(src), reflect.TypeOf(0))
+		assertNoError(t, err)
+		for err == nil {
+			err = d.Next(&n)
+		}
+		assert(t, err != io.EOF, "Expected error for %q", src)
+		assertEqual(t, d.Next(&n), err)
+	}
+}
+
+func BenchmarkArrayDecoder(b *testing.B) {
+	type Item struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+	}
+	buf := []byte{'['}
+	for i := 0; i < 10000; i++ {
+		if i > 0 {
+			buf = append(buf, ',')
+		}
+		buf = append(buf, `{"id":`...)
+		buf = strconv.AppendInt(buf, int64(i), 10)
+		buf = append(buf, `,"name":"foo"}`...)
+	}
+	buf = append(buf, ']')
+	src := string(buf)
+	b.SetBytes(int64(len(src)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		d, err := NewArrayDecoder(strings.NewReader(src), reflect.TypeOf(Item{}))
+		if err != nil {
+			b.Fatal(err)
+		}
+		item := Item{}
+		for err == nil {
+			err = d.Next(&item)
+		}
+		if err != io.EOF {
+			b.Fatal(err)
+		}
+	}
+}
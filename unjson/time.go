@@ -0,0 +1,205 @@
+package unjson
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/alxarch/njson"
+	"github.com/alxarch/njson/numjson"
+	"github.com/alxarch/njson/strjson"
+)
+
+// Time formats for Options.TimeFormat other than time layouts.
+// Without a format time.Time values are encoded and decoded with their JSON methods.
+const (
+	TimeFormatUnix       = "unix"   // Unix time in seconds
+	TimeFormatUnixMillis = "unixms" // Unix time in milliseconds
+)
+
+// Duration formats for Options.DurationFormat.
+// Without a format time.Duration values are encoded and decoded as integers.
+const (
+	DurationFormatNanoseconds = "ns"   // Number of nanoseconds
+	DurationFormatText        = "text" // String in the format of time.Duration.String()
+)
+
+var (
+	typTime     = reflect.TypeOf(time.Time{})
+	typDuration = reflect.TypeOf(time.Duration(0))
+	typTimePtr  = reflect.PtrTo(typTime)
+)
+
+// formatTime checks if time.Time values use TimeFormat instead of their JSON methods.
+func (o *Options) formatTime() bool {
+	return o != nil && o.TimeFormat != ""
+}
+
+// formatDuration checks if time.Duration values use DurationFormat instead of integers.
+func (o *Options) formatDuration() bool {
+	return o != nil && o.DurationFormat != ""
+}
+
+// addrDecoder decodes addressable values with a decoder for their pointer type.
+type addrDecoder struct {
+	decoder decoder
+}
+
+func (d addrDecoder) decode(v reflect.Value, n njson.Node) error {
+	return d.decoder.decode(v.Addr(), n)
+}
+
+// withFieldFormat overrides the time and duration formats with struct tag options.
+func (o *Options) withFieldFormat(hints hint) *Options {
+	timeFormat, durationFormat := o.TimeFormat, o.DurationFormat
+	switch {
+	case hints&hintUnixMillis == hintUnixMillis:
+		timeFormat = TimeFormatUnixMillis
+	case hints&hintUnix == hintUnix:
+		timeFormat = TimeFormatUnix
+	}
+	switch {
+	case hints&hintText == hintText:
+		durationFormat = DurationFormatText
+	case hints&hintNanoseconds == hintNanoseconds:
+		durationFormat = DurationFormatNanoseconds
+	}
+	if timeFormat == o.TimeFormat && durationFormat == o.DurationFormat {
+		return o
+	}
+	fo := *o
+	fo.TimeFormat, fo.DurationFormat = timeFormat, durationFormat
+	return &fo
+}
+
+// hasTimeFormat checks if struct tag time or duration formats apply to a type.
+func hasTimeFormat(typ reflect.Type) bool {
+	switch typ {
+	case typTime, typDuration:
+		return true
+	}
+	switch typ.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Array:
+		return hasTimeFormat(typ.Elem())
+	default:
+		return false
+	}
+}
+
+type timeEncoder string
+
+func newTimeEncoder(options *Options) timeEncoder {
+	if options == nil || options.TimeFormat == "" {
+		return time.RFC3339Nano
+	}
+	return timeEncoder(options.TimeFormat)
+}
+
+func (format timeEncoder) encode(out []byte, v reflect.Value) ([]byte, error) {
+	t := v.Interface().(time.Time)
+	switch format {
+	case TimeFormatUnix:
+		return strconv.AppendInt(out, t.Unix(), 10), nil
+	case TimeFormatUnixMillis:
+		return strconv.AppendInt(out, t.UnixNano()/int64(time.Millisecond), 10), nil
+	default:
+		out = append(out, delimString)
+		out = strjson.AppendEscaped(out, t.Format(string(format)), false)
+		out = append(out, delimString)
+		return out, nil
+	}
+}
+
+type timeDecoder string
+
+func newTimeDecoder(options *Options) timeDecoder {
+	if options == nil || options.TimeFormat == "" {
+		return time.RFC3339Nano
+	}
+	return timeDecoder(options.TimeFormat)
+}
+
+func (format timeDecoder) decode(v reflect.Value, n njson.Node) error {
+	var t time.Time
+	switch raw, typ := n.Data(); {
+	case typ == njson.TypeNull:
+	case format == TimeFormatUnix || format == TimeFormatUnixMillis:
+		if typ != njson.TypeNumber {
+			return n.TypeError(njson.TypeNumber | njson.TypeNull)
+		}
+		if i, ok := numjson.ParseInt(raw); ok {
+			if format == TimeFormatUnixMillis {
+				t = time.Unix(i/1000, i%1000*int64(time.Millisecond)).UTC()
+			} else {
+				t = time.Unix(i, 0).UTC()
+			}
+			break
+		}
+		f, err := numjson.Parse(raw)
+		if err != nil {
+			return fmt.Errorf("Invalid %s time: %w", format, err)
+		}
+		if format == TimeFormatUnixMillis {
+			f /= 1000
+		}
+		sec, frac := math.Modf(f)
+		t = time.Unix(int64(sec), int64(frac*float64(time.Second))).UTC()
+	case typ == njson.TypeString:
+		var err error
+		if t, err = time.Parse(string(format), strjson.Unescaped(raw)); err != nil {
+			return err
+		}
+	default:
+		return n.TypeError(njson.TypeString | njson.TypeNull)
+	}
+	v.Set(reflect.ValueOf(t))
+	return nil
+}
+
+type durationEncoder string
+
+func newDurationEncoder(options *Options) durationEncoder {
+	if options == nil || options.DurationFormat == "" {
+		return DurationFormatNanoseconds
+	}
+	return durationEncoder(options.DurationFormat)
+}
+
+func (format durationEncoder) encode(out []byte, v reflect.Value) ([]byte, error) {
+	d := time.Duration(v.Int())
+	switch format {
+	case DurationFormatNanoseconds:
+		return strconv.AppendInt(out, int64(d), 10), nil
+	case DurationFormatText:
+		out = append(out, delimString)
+		out = append(out, d.String()...)
+		out = append(out, delimString)
+		return out, nil
+	default:
+		return out, fmt.Errorf("Invalid duration format %q", string(format))
+	}
+}
+
+// durationDecoder decodes numbers as nanoseconds and strings using time.ParseDuration
+type durationDecoder struct{}
+
+func (durationDecoder) decode(v reflect.Value, n njson.Node) error {
+	switch raw, typ := n.Data(); typ {
+	case njson.TypeNull:
+		v.SetInt(0)
+		return nil
+	case njson.TypeNumber:
+		return intDecoder{}.decode(v, n)
+	case njson.TypeString:
+		d, err := time.ParseDuration(strjson.Unescaped(raw))
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	default:
+		return n.TypeError(njson.TypeNumber | njson.TypeString | njson.TypeNull)
+	}
+}
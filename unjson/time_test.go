@@ -0,0 +1,90 @@
+package unjson
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/alxarch/njson"
+)
+
+func TestTimeFormat(t *testing.T) {
+	type Event struct {
+		Time     time.Time     `json:"time"`
+		Created  time.Time     `json:"created,unixms"`
+		Updated  *time.Time    `json:"updated,unix,omitempty"`
+		Timeout  time.Duration `json:"timeout"`
+		Interval time.Duration `json:"interval,text"`
+	}
+	ts := time.Date(2018, 10, 25, 23, 25, 22, 500*int(time.Millisecond), time.UTC)
+	e := Event{ts, ts, &ts, time.Second, time.Minute}
+	data, err := Marshal(&e)
+	assertNoError(t, err)
+	assertEqual(t, string(data), `{"time":"2018-10-25T23:25:22.5Z","created":1540509922500,"updated":1540509922,"timeout":1000000000,"interval":"1m0s"}`)
+	v := Event{}
+	assertNoError(t, Unmarshal(data, &v))
+	second := ts.Truncate(time.Second)
+	assertEqual(t, v, Event{ts, ts, &second, time.Second, time.Minute})
+
+	c := Cache{Options: Options{TimeFormat: TimeFormatUnix, DurationFormat: DurationFormatText}}
+	enc, err := c.Encoder(reflect.TypeOf(e))
+	assertNoError(t, err)
+	data, err = enc.Encode(nil, &e)
+	assertNoError(t, err)
+	assertEqual(t, string(data), `{"time":1540509922,"created":1540509922500,"updated":1540509922,"timeout":"1s","interval":"1m0s"}`)
+	dec, err := c.Decoder(reflect.TypeOf(&e))
+	assertNoError(t, err)
+	d := njson.Document{}
+	n, _, err := d.Parse(`{"time":1540509922.5,"created":1540509922500,"updated":null,"timeout":"1.5s","interval":60000000000}`)
+	assertNoError(t, err)
+	v = Event{}
+	assertNoError(t, dec.Decode(&v, n))
+	assertEqual(t, v, Event{ts, ts, nil, 1500 * time.Millisecond, time.Minute})
+
+	// Time layouts
+	c = Cache{Options: Options{TimeFormat: "2006-01-02"}}
+	dec, err = c.Decoder(reflect.TypeOf(&e))
+	assertNoError(t, err)
+	n, _, err = d.Parse(`{"time":"2018-10-25","created":"2018-10-25"}`)
+	assertNoError(t, err)
+	err = dec.Decode(&v, n)
+	var de *DecodeError
+	assert(t, err != nil && errors.As(err, &de), "Invalid error %v", err)
+	assertEqual(t, de.Path(), "$.created")
+
+	var tm time.Time
+	dec, err = c.Decoder(reflect.TypeOf(&tm))
+	assertNoError(t, err)
+	assertNoError(t, dec.Decode(&tm, n.Get("time")))
+	assertEqual(t, tm, time.Date(2018, 10, 25, 0, 0, 0, 0, time.UTC))
+}
+
+func TestTimeDefaultMethods(t *testing.T) {
+	// Without a format time.Time values use their own JSON methods
+	_, err := Marshal(time.Date(10000, 1, 1, 0, 0, 0, 0, time.UTC))
+	assert(t, err != nil, "Expected MarshalJSON error")
+	c := Cache{Options: Options{TimeFormat: time.RFC3339}}
+	enc, err := c.Encoder(typTime)
+	assertNoError(t, err)
+	data, err := enc.Encode(nil, time.Date(10000, 1, 1, 0, 0, 0, 0, time.UTC))
+	assertNoError(t, err)
+	assertEqual(t, string(data), `"10000-01-01T00:00:00Z"`)
+
+	type Batch struct {
+		Times   []time.Time      `json:"times,unixms"`
+		Dates   []time.Time      `json:"dates"`
+		Created *time.Time       `json:"created,unix"`
+		Updated *time.Time       `json:"updated"`
+		Waits   []time.Duration  `json:"waits,text"`
+		Sleeps  [1]time.Duration `json:"sleeps"`
+	}
+	ts := time.Date(2018, 10, 25, 23, 25, 22, 0, time.UTC)
+	b := Batch{[]time.Time{ts}, []time.Time{ts}, &ts, nil, []time.Duration{time.Second}, [1]time.Duration{time.Second}}
+	data, err = Marshal(&b)
+	assertNoError(t, err)
+	assertEqual(t, string(data), `{"times":[1540509922000],"dates":["2018-10-25T23:25:22Z"],"created":1540509922,"updated":null,"waits":["1s"],"sleeps":[1000000000]}`)
+	v := Batch{}
+	assertNoError(t, Unmarshal(data, &v))
+	assertEqual(t, v, b)
+}
@@ -38,7 +38,12 @@ import (
 // It delegates to `UnmarshalFromJSON` by allocating a new string.
 // To avoid allocations use `UnmarshalFromString` or `UnmarshalFromNode`
 func Unmarshal(data []byte, x interface{}) error {
-	return UnmarshalFromString(string(data), x)
+	return defaultCache.UnmarshalFromString(string(data), x)
+}
+
+// Unmarshal unmarshals JSON data using cache.Options
+func (c *Cache) Unmarshal(data []byte, x interface{}) error {
+	return c.UnmarshalFromString(string(data), x)
 }
 
 // UnmarshalFromNode unmarshals from an njson.Node
@@ -47,10 +52,15 @@ func Unmarshal(data []byte, x interface{}) error {
 // In order to use custom options for a `Decoder` and avoid lock congestion
 // on the registry, create a local `Decoder` instance with `NewTypeDecoder`
 func UnmarshalFromNode(n njson.Node, x interface{}) error {
+	return defaultCache.UnmarshalFromNode(n, x)
+}
+
+// UnmarshalFromNode unmarshals from an njson.Node using cache.Options
+func (c *Cache) UnmarshalFromNode(n njson.Node, x interface{}) error {
 	if x == nil {
-		return errInvalidValueType
+		return ErrInvalidValueType
 	}
-	dec, err := defaultCache.Decoder(reflect.TypeOf(x))
+	dec, err := c.Decoder(reflect.TypeOf(x))
 	if err != nil {
 		return err
 	}
@@ -62,11 +72,17 @@ func UnmarshalFromNode(n njson.Node, x interface{}) error {
 // It borrows a blank `njson.Document` from `njson.Blank` to parse
 // the JSON string and a `Decoder` with the default options from package-wide
 // cache of `Dec`Decoder` instances.
+// To decode with other options, such as `DisallowUnknownFields`, use the methods of a `Cache`.
 func UnmarshalFromString(s string, x interface{}) (err error) {
+	return defaultCache.UnmarshalFromString(s, x)
+}
+
+// UnmarshalFromString unmarshals from a JSON string using cache.Options
+func (c *Cache) UnmarshalFromString(s string, x interface{}) (err error) {
 	if x == nil {
-		return errInvalidValueType
+		return ErrInvalidValueType
 	}
-	d, err := defaultCache.Decoder(reflect.TypeOf(x))
+	d, err := c.Decoder(reflect.TypeOf(x))
 	if err != nil {
 		return
 	}
@@ -1,7 +1,10 @@
 package unjson
 
 import (
+	"errors"
+	"math"
 	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/alxarch/njson"
@@ -236,3 +239,344 @@ func TestUnmarshalFromString(t *testing.T) {
 		})
 	}
 }
+
+func TestUnmarshalErrors(t *testing.T) {
+	var (
+		typeErr        *njson.TypeError
+		unsupportedErr *njson.UnsupportedTypeError
+		s              struct{ Foo string }
+	)
+	err := UnmarshalFromString(`{"Foo":42}`, &s)
+	assert(t, errors.As(err, &typeErr), "Invalid error %v", err)
+	assertEqual(t, typeErr.Type, njson.TypeNumber)
+	assertEqual(t, typeErr.Want, njson.TypeString)
+
+	var ch chan int
+	err = UnmarshalFromString(`{}`, &ch)
+	assert(t, errors.As(err, &unsupportedErr), "Invalid error %v", err)
+	assertEqual(t, unsupportedErr.Type, reflect.TypeOf(ch))
+	_, err = Marshal(math.NaN())
+	assert(t, errors.Is(err, ErrUnsupportedValue), "Invalid error %v", err)
+	err = UnmarshalFromString(`{}`, nil)
+	assert(t, errors.Is(err, ErrInvalidValueType), "Invalid error %v", err)
+	err = UnmarshalFromString(`{}`, s)
+	assert(t, errors.Is(err, ErrNotPointer), "Invalid error %v", err)
+	dec, err := NewTypeDecoder(nil, "")
+	assertNoError(t, err)
+	assertEqual(t, dec, Decoder(interfaceDecoder{}))
+	for _, typ := range []reflect.Type{reflect.TypeOf(s), reflect.TypeOf(ch)} {
+		_, err = NewTypeDecoder(typ, "")
+		assert(t, errors.Is(err, ErrNotPointer), "Invalid error %v", err)
+		assert(t, !errors.As(err, &unsupportedErr), "Invalid error %v", err)
+	}
+}
+
+func TestUnmarshalDecodeError(t *testing.T) {
+	type Customer struct {
+		ID string `json:"id"`
+	}
+	type Order struct {
+		Customer *Customer `json:"customer"`
+	}
+	type Request struct {
+		Orders []Order           `json:"orders"`
+		Tags   map[string][2]int `json:"tags"`
+	}
+	for _, tc := range []struct {
+		input string
+		path  string
+		field string
+		typ   reflect.Type
+		err   error
+	}{
+		{
+			`{"orders":[{},{},{"customer":{"id":42}}]}`,
+			"$.orders[2].customer.id", "Orders.Customer.ID", reflect.TypeOf(""),
+			&njson.TypeError{Type: njson.TypeNumber, Want: njson.TypeString},
+		},
+		{
+			`{"tags":{"foo bar":[1,"2"]}}`,
+			`$.tags["foo bar"][1]`, "Tags", reflect.TypeOf(0),
+			&njson.TypeError{Type: njson.TypeString, Want: njson.TypeNumber},
+		},
+		{
+			`[]`,
+			"$", "", reflect.TypeOf(Request{}),
+			&njson.TypeError{Type: njson.TypeArray, Want: njson.TypeObject | njson.TypeNull},
+		},
+	} {
+		r := Request{}
+		err := UnmarshalFromString(tc.input, &r)
+		var e *DecodeError
+		assert(t, errors.As(err, &e), "Invalid error %v", err)
+		assertEqual(t, e.Path(), tc.path)
+		assertEqual(t, e.Field, tc.field)
+		assertEqual(t, e.Type, tc.typ)
+		assertEqual(t, e.Err, tc.err)
+	}
+	r := Request{}
+	err := UnmarshalFromString(`{"orders":[{"customer":{"id":42}}]}`, &r)
+	assertEqual(t, err.Error(), "Invalid type Number not in [String] at $.orders[0].customer.id decoding field Orders.Customer.ID of type string")
+}
+
+func TestDisallowUnknownFields(t *testing.T) {
+	type Item struct {
+		ID int `json:"id"`
+	}
+	type Request struct {
+		Name  string `json:"name"`
+		Items []Item `json:"items"`
+	}
+	c := Cache{Options: Options{DisallowUnknownFields: true}}
+	dec, err := c.Decoder(reflect.TypeOf(&Request{}))
+	assertNoError(t, err)
+	d := njson.Document{}
+	n, _, err := d.Parse(`{"name":"foo","items":[{"id":1},{"id":2,"ID":3,"nmae":"bar"}]}`)
+	assertNoError(t, err)
+	r := Request{}
+	err = dec.Decode(&r, n)
+	var e *DecodeError
+	assert(t, errors.As(err, &e), "Invalid error %v", err)
+	assertEqual(t, e.Path(), "$.items[1]")
+	assertEqual(t, e.Err, &UnknownFieldsError{
+		Keys:  []string{"ID", "nmae"},
+		Paths: []string{"$.items[1].ID", "$.items[1].nmae"},
+	})
+	assertEqual(t, err.Error(), `Unknown fields ["ID" "nmae"] at $.items[1] decoding field Items of type unjson.Item`)
+
+	// Default options ignore unknown keys
+	assertNoError(t, UnmarshalFromNode(n, &r))
+	assertEqual(t, r, Request{Name: "foo", Items: []Item{{1}, {2}}})
+
+	ld := NewLineDecoder(strings.NewReader("{\"name\":\"foo\"}\n{\"name\":\"bar\",\"foo\":1}\n"))
+	ld.Cache = &c
+	assertNoError(t, ld.Decode(&r))
+	assertEqual(t, r.Name, "foo")
+	err = ld.Decode(&r)
+	assert(t, errors.As(err, &e), "Invalid error %v", err)
+	assertEqual(t, e.Path(), "$")
+	assertEqual(t, e.Err, &UnknownFieldsError{Keys: []string{"foo"}, Paths: []string{"$.foo"}})
+
+	// Cache methods use the cache options
+	err = c.Unmarshal([]byte(`{"name":"foo","foo":1}`), &r)
+	assert(t, errors.As(err, &e), "Invalid error %v", err)
+	assertEqual(t, e.Err, &UnknownFieldsError{Keys: []string{"foo"}, Paths: []string{"$.foo"}})
+	err = c.UnmarshalFromString(`{"name":"foo","foo":1}`, &r)
+	assert(t, errors.As(err, &e), "Invalid error %v", err)
+	err = c.UnmarshalFromNode(n, &r)
+	assert(t, errors.As(err, &e), "Invalid error %v", err)
+	ad, err := c.NewArrayDecoder(strings.NewReader(`[{"id":1},{"id":2,"ID":3}]`), reflect.TypeOf(Item{}))
+	assertNoError(t, err)
+	item := Item{}
+	assertNoError(t, ad.Next(&item))
+	assertEqual(t, item, Item{1})
+	err = ad.Next(&item)
+	assert(t, errors.As(err, &e), "Invalid error %v", err)
+	assertEqual(t, e.Err, &UnknownFieldsError{Keys: []string{"ID"}, Paths: []string{"$.ID"}})
+
+	// Nested unknown keys have the full path
+	type Order struct {
+		Request Request `json:"request"`
+	}
+	o := Order{}
+	err = c.UnmarshalFromString(`{"request":{"items":[{"id":1},{"id":2,"a b":{"c":1}}]}}`, &o)
+	assert(t, errors.As(err, &e), "Invalid error %v", err)
+	assertEqual(t, e.Path(), "$.request.items[1]")
+	assertEqual(t, e.Err, &UnknownFieldsError{
+		Keys:  []string{"a b"},
+		Paths: []string{`$.request.items[1]["a b"]`},
+	})
+}
+
+func TestUnmarshalRequiredDefault(t *testing.T) {
+	type Item struct {
+		ID   int      `json:"id,required"`
+		Qty  int      `json:"qty,default=1"`
+		Tags []string `json:"tags,omitempty,default=[\"a\",\"b\"]"`
+		Note *string  `json:"note,default=\"none\""`
+	}
+	type Order struct {
+		Items []Item `json:"items,required"`
+	}
+	o := Order{}
+	assertNoError(t, UnmarshalFromString(`{"items":[{"id":1},{"id":2,"qty":3,"tags":[],"note":null}]}`, &o))
+	none := "none"
+	assertEqual(t, o, Order{Items: []Item{
+		{ID: 1, Qty: 1, Tags: []string{"a", "b"}, Note: &none},
+		{ID: 2, Qty: 3, Tags: []string{}},
+	}})
+	// Defaults are decoded on every use
+	o.Items[0].Tags[0] = "c"
+	assertNoError(t, UnmarshalFromString(`{"items":[{"id":1}]}`, &o))
+	assertEqual(t, o.Items[0].Tags, []string{"a", "b"})
+
+	err := UnmarshalFromString(`{"items":[{"id":1},{"qty":2}]}`, &o)
+	var e *DecodeError
+	assert(t, errors.As(err, &e), "Invalid error %v", err)
+	assertEqual(t, e.Path(), "$.items[1]")
+	assertEqual(t, e.Err, &MissingFieldsError{Keys: []string{"id"}})
+	err = UnmarshalFromString(`{}`, &o)
+	assert(t, errors.As(err, &e), "Invalid error %v", err)
+	assertEqual(t, e.Path(), "$")
+	assertEqual(t, e.Err, &MissingFieldsError{Keys: []string{"items"}})
+
+	type Invalid struct {
+		N int `json:"n,default=\"foo\""`
+	}
+	_, err = NewTypeDecoder(reflect.TypeOf(&Invalid{}), "")
+	assert(t, err != nil, "Expected invalid default error")
+}
+
+func TestUnmarshalQuoted(t *testing.T) {
+	type Payment struct {
+		ID     int64   `json:"id,string"`
+		Amount float64 `json:"amount,string"`
+		Ref    *uint   `json:"ref,string"`
+		OK     bool    `json:"ok,string"`
+		Qty    int     `json:"qty,string,default=1"`
+	}
+	p := Payment{}
+	assertNoError(t, UnmarshalFromString(`{"id":"1234567890123","amount":" 4.5 ","ref":"42","ok":"true"}`, &p))
+	ref := uint(42)
+	assertEqual(t, p, Payment{ID: 1234567890123, Amount: 4.5, Ref: &ref, OK: true, Qty: 1})
+	// Unquoted values and null are accepted
+	assertNoError(t, UnmarshalFromString(`{"id":7,"amount":"1e2","ref":null,"ok":false,"qty":"3"}`, &p))
+	assertEqual(t, p, Payment{ID: 7, Amount: 100, Qty: 3})
+
+	var e *DecodeError
+	err := UnmarshalFromString(`{"id":"foo"}`, &p)
+	assert(t, errors.As(err, &e), "Invalid error %v", err)
+	assertEqual(t, e.Path(), "$.id")
+	err = UnmarshalFromString(`{"ok":"1"}`, &p)
+	assert(t, errors.As(err, &e), "Invalid error %v", err)
+	assertEqual(t, e.Path(), "$.ok")
+	err = UnmarshalFromString(`{"id":"\"1\""}`, &p)
+	assert(t, errors.As(err, &e), "Invalid error %v", err)
+	assertEqual(t, e.Path(), "$.id")
+	err = UnmarshalFromString(`{"ref":"-1"}`, &p)
+	assert(t, errors.As(err, &e), "Invalid error %v", err)
+	assertEqual(t, e.Path(), "$.ref")
+
+	// Quoted values round-trip without losing precision
+	type Big struct {
+		ID    int64   `json:"id,string"`
+		Max   *uint64 `json:"max,string"`
+		Ratio float64 `json:"ratio,string"`
+	}
+	max := uint64(math.MaxUint64)
+	b := Big{ID: 9007199254740993, Max: &max, Ratio: 0.1234567890123}
+	data, err := Marshal(b)
+	assertNoError(t, err)
+	assertEqual(t, string(data), `{"id":"9007199254740993","max":"18446744073709551615","ratio":"0.1234567890123"}`)
+	bb := Big{}
+	assertNoError(t, Unmarshal(data, &bb))
+	assertEqual(t, bb, b)
+}
+
+func TestUnmarshalRemain(t *testing.T) {
+	type Event struct {
+		ID    int                    `json:"id"`
+		Extra map[string]interface{} `json:",remain"`
+	}
+	type RawEvent struct {
+		ID    int    `json:"id"`
+		Extra []byte `json:",inline"`
+	}
+	src := `{"id":1,"name":"foo","tags":["a"],"a\"b":null}`
+	e := Event{}
+	assertNoError(t, UnmarshalFromString(src, &e))
+	assertEqual(t, e, Event{ID: 1, Extra: map[string]interface{}{
+		"name": "foo",
+		"tags": []interface{}{"a"},
+		`a"b`:  nil,
+	}})
+	raw := RawEvent{}
+	assertNoError(t, UnmarshalFromString(src, &raw))
+	assertEqual(t, raw.ID, 1)
+	assertEqual(t, string(raw.Extra), `{"name":"foo","tags":["a"],"a\"b":null}`)
+	data, err := Marshal(&raw)
+	assertNoError(t, err)
+	assertEqual(t, string(data), src)
+
+	// Unknown keys are replaced on each decode
+	assertNoError(t, UnmarshalFromString(`{"id":2,"bar":2}`, &e))
+	assertEqual(t, e, Event{ID: 2, Extra: map[string]interface{}{"bar": 2.0}})
+	assertNoError(t, UnmarshalFromString(`{"id":3}`, &raw))
+	assertEqual(t, raw, RawEvent{ID: 3})
+	// The caller's slice is not overwritten
+	extra := []byte(`{"foo":"bar"}`)
+	raw.Extra = extra
+	assertNoError(t, UnmarshalFromString(`{"id":4,"baz":1}`, &raw))
+	assertEqual(t, string(raw.Extra), `{"baz":1}`)
+	assertEqual(t, string(extra), `{"foo":"bar"}`)
+
+	// Remain fields collect unknown keys even with DisallowUnknownFields
+	c := Cache{Options: Options{DisallowUnknownFields: true}}
+	dec, err := c.Decoder(reflect.TypeOf(&e))
+	assertNoError(t, err)
+	d := njson.Document{}
+	n, _, err := d.Parse(`{"id":4,"foo":"bar"}`)
+	assertNoError(t, err)
+	assertNoError(t, dec.Decode(&e, n))
+	assertEqual(t, e, Event{ID: 4, Extra: map[string]interface{}{"foo": "bar"}})
+
+	type Invalid struct {
+		Extra string `json:",remain"`
+	}
+	_, err = NewTypeDecoder(reflect.TypeOf(&Invalid{}), "")
+	assert(t, err != nil, "Expected invalid remain field error")
+}
+
+func TestFieldCase(t *testing.T) {
+	type User struct {
+		UserName  string
+		CreatedAt int64
+		Admin     bool `json:"is_admin"`
+	}
+	u := User{"foo", 42, true}
+	for mode, want := range map[string]string{
+		"":      `{"UserName":"foo","CreatedAt":42,"is_admin":true}`,
+		"snake": `{"user_name":"foo","created_at":42,"is_admin":true}`,
+		"lower": `{"username":"foo","createdat":42,"is_admin":true}`,
+		"camel": `{"userName":"foo","createdAt":42,"is_admin":true}`,
+		"Camel": `{"UserName":"foo","CreatedAt":42,"is_admin":true}`,
+	} {
+		c := Cache{Options: Options{FieldCase: mode}}
+		enc, err := c.Encoder(reflect.TypeOf(u))
+		assertNoError(t, err)
+		data, err := enc.Encode(nil, &u)
+		assertNoError(t, err)
+		assertEqual(t, string(data), want)
+		dec, err := c.Decoder(reflect.TypeOf(&u))
+		assertNoError(t, err)
+		d := njson.Document{}
+		n, _, err := d.Parse(want)
+		assertNoError(t, err)
+		v := User{}
+		assertNoError(t, dec.Decode(&v, n))
+		assertEqual(t, v, u)
+	}
+}
+
+func TestCaseInsensitive(t *testing.T) {
+	type Item struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+		NAME string `json:"NAME"`
+	}
+	c := Cache{Options: Options{CaseInsensitive: true}}
+	dec, err := c.Decoder(reflect.TypeOf(&Item{}))
+	assertNoError(t, err)
+	d := njson.Document{}
+	n, _, err := d.Parse(`{"Id":1,"NAME":"foo","nAmE":"bar"}`)
+	assertNoError(t, err)
+	v := Item{}
+	assertNoError(t, dec.Decode(&v, n))
+	// Exact matches are preferred
+	assertEqual(t, v, Item{ID: 1, Name: "bar", NAME: "foo"})
+
+	// Default options match keys exactly
+	v = Item{}
+	assertNoError(t, UnmarshalFromNode(n, &v))
+	assertEqual(t, v, Item{NAME: "foo"})
+}